@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cache stores API responses on disk, keyed by their API path.
+type Cache struct {
+	dir string
+}
+
+// DefaultCacheDir returns termhnal's directory under the user's cache
+// directory, e.g. $XDG_CACHE_HOME/termhnal.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "termhnal"), nil
+}
+
+// CacheNamespace names the directory below the cache directory which holds
+// responses from the API at baseURL, so responses from a mirror or a test
+// server never mix with those from another API.
+func CacheNamespace(baseURL *url.URL) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '.', r == '-':
+			return r
+		}
+
+		return '_'
+	}, baseURL.Host+strings.TrimSuffix(baseURL.Path, "/"))
+
+	if strings.Trim(name, "._") == "" {
+		return "_"
+	}
+
+	return name
+}
+
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(strings.TrimPrefix(key, "/")))
+}
+
+// Get returns the response cached for key and when it was stored.
+func (c *Cache) Get(key string) ([]byte, time.Time, bool) {
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	return body, info.ModTime(), true
+}
+
+// Put stores body for key, replacing any previous response.
+func (c *Cache) Put(key string, body []byte) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Expire marks the response cached for key as stale without removing it,
+// so it is refetched when online but still served offline.
+func (c *Cache) Expire(key string) error {
+	err := os.Chtimes(c.path(key), time.Time{}, time.Unix(0, 0))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// Clear removes every cached response.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// TTL returns how long the response body for key stays fresh. Story lists
+// churn constantly whereas items settle down as they age. Dead and deleted
+// items rarely change, but dead items can be vouched for, so they expire
+// too, and Expire can mark them stale when updates say they changed.
+func TTL(key string, body []byte) time.Duration {
+	if strings.HasSuffix(key, "stories.json") {
+		return 5 * time.Minute
+	} else if key == "/updates.json" {
+		return 0
+	} else if strings.HasPrefix(key, "/user/") {
+		return 10 * time.Minute
+	}
+
+	var item struct {
+		Time    int64 `json:"time"`
+		Dead    bool  `json:"dead"`
+		Deleted bool  `json:"deleted"`
+	}
+
+	if err := json.Unmarshal(body, &item); err != nil || item.Time == 0 {
+		return time.Minute
+	}
+
+	if item.Deleted {
+		return 7 * 24 * time.Hour
+	} else if item.Dead {
+		return 24 * time.Hour
+	}
+
+	switch age := time.Since(time.Unix(item.Time, 0)); {
+	case age < time.Hour:
+		return time.Minute
+	case age < 24*time.Hour:
+		return 10 * time.Minute
+	case age < 14*24*time.Hour:
+		// items can no longer be voted on or replied to after two weeks
+		return time.Hour
+	default:
+		return 7 * 24 * time.Hour
+	}
+}
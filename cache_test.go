@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheNamespace(t *testing.T) {
+	namespace := func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return CacheNamespace(u)
+	}
+
+	for _, tt := range []struct {
+		url, want string
+	}{
+		{"https://hacker-news.firebaseio.com/v0", "hacker-news.firebaseio.com_v0"},
+		{"https://hacker-news.firebaseio.com/v0/", "hacker-news.firebaseio.com_v0"},
+		{"http://127.0.0.1:8080/v0", "127.0.0.1_8080_v0"},
+		{"http://mirror.internal/../..", "mirror.internal_.._.."},
+		{"", "_"},
+	} {
+		if got := namespace(tt.url); got != tt.want {
+			t.Errorf("CacheNamespace(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCacheNamespacesApart(t *testing.T) {
+	dir := t.TempDir()
+	api, _ := url.Parse("https://hacker-news.firebaseio.com/v0")
+	mirror, _ := url.Parse("http://localhost:8080/v0")
+
+	NewCache(filepath.Join(dir, CacheNamespace(api))).Put("/item/1.json", []byte(`{"id":1}`))
+	if _, _, ok := NewCache(filepath.Join(dir, CacheNamespace(mirror))).Get("/item/1.json"); ok {
+		t.Error("the mirror's cache holds the API's response")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	item := func(age time.Duration, flags string) []byte {
+		return []byte(fmt.Sprintf(`{"id":1,"time":%d%s}`, time.Now().Add(-age).Unix(), flags))
+	}
+
+	for _, tt := range []struct {
+		key  string
+		body []byte
+		want time.Duration
+	}{
+		{"/topstories.json", []byte("[1,2]"), 5 * time.Minute},
+		{"/updates.json", []byte("{}"), 0},
+		{"/user/pg.json", []byte(`{"id":"pg"}`), 10 * time.Minute},
+		{"/item/1.json", []byte("null"), time.Minute},
+		{"/item/1.json", item(time.Minute, ""), time.Minute},
+		{"/item/1.json", item(2*time.Hour, ""), 10 * time.Minute},
+		{"/item/1.json", item(3*24*time.Hour, ""), time.Hour},
+		{"/item/1.json", item(30*24*time.Hour, ""), 7 * 24 * time.Hour},
+		{"/item/1.json", item(time.Minute, `,"dead":true`), 24 * time.Hour},
+		{"/item/1.json", item(time.Minute, `,"deleted":true`), 7 * 24 * time.Hour},
+	} {
+		if got := TTL(tt.key, tt.body); got != tt.want {
+			t.Errorf("TTL(%q, %s) = %s, want %s", tt.key, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestCacheExpireDeadItem(t *testing.T) {
+	c := NewCache(t.TempDir())
+	body := []byte(fmt.Sprintf(`{"id":1,"time":%d,"dead":true}`, time.Now().Unix()))
+	if err := c.Put("/item/1.json", body); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Expire("/item/1.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	// e.g. the comment was vouched for, so it must be fetched again
+	if _, stored, ok := c.Get("/item/1.json"); !ok || time.Since(stored) < TTL("/item/1.json", body) {
+		t.Error("expired dead item is still fresh")
+	}
+}
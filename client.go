@@ -1,102 +1,569 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+var (
+	// ErrUnavailable is returned in offline mode for anything which has
+	// not been cached.
+	ErrUnavailable = errors.New("not available offline")
+
+	ErrNotFound    = errors.New("not found")
+	ErrRateLimited = errors.New("rate limited")
+	ErrServer      = errors.New("server error")
+)
+
+// StatusError is returned for responses other than 200 OK. It matches
+// ErrNotFound, ErrRateLimited or ErrServer with errors.Is.
+type StatusError struct {
+	URL        string
+	StatusCode int
+
+	// RetryAfter is the delay requested by the server, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+
+	return false
+}
+
+// DecodeError is returned for responses which are not the expected JSON.
+type DecodeError struct {
+	URL string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: decode: %v", e.URL, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
 // ref: https://github.com/HackerNews/API
 type HN struct {
-	baseURL *url.URL
+	baseURL   *url.URL
+	client    *http.Client
+	userAgent string
+	workers   int
+	cache     *Cache
+	searchURL *url.URL
+	offline   bool
+	streaming bool
+	retry     RetryPolicy
+
+	scheduler *Scheduler
+}
+
+type HNOption func(*HN)
+
+// WithBaseURL points the client at an alternative API root, e.g. a mirror
+// or a local fake server.
+func WithBaseURL(baseURL *url.URL) HNOption {
+	return func(h *HN) {
+		h.baseURL = baseURL
+	}
+}
+
+// WithSearchURL points searches at an alternative Algolia-style search API
+// root, e.g. a local stub.
+func WithSearchURL(searchURL *url.URL) HNOption {
+	return func(h *HN) {
+		h.searchURL = searchURL
+	}
+}
+
+// WithHTTPClient replaces http.DefaultClient for all requests.
+func WithHTTPClient(client *http.Client) HNOption {
+	return func(h *HN) {
+		h.client = client
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) HNOption {
+	return func(h *HN) {
+		h.userAgent = userAgent
+	}
 }
 
-func NewHN() *HN {
+// WithWorkers sets the maximum number of concurrent requests.
+func WithWorkers(n int) HNOption {
+	return func(h *HN) {
+		h.workers = n
+	}
+}
+
+// WithRetry sets how transient failures are retried.
+func WithRetry(p RetryPolicy) HNOption {
+	return func(h *HN) {
+		h.retry = p
+	}
+}
+
+// WithCache serves responses from c while they are fresh and stores every
+// successful response in it.
+func WithCache(c *Cache) HNOption {
+	return func(h *HN) {
+		h.cache = c
+	}
+}
+
+// WithOffline serves everything from the cache, regardless of age, and
+// never touches the network. It has no effect without WithCache.
+func WithOffline() HNOption {
+	return func(h *HN) {
+		h.offline = true
+	}
+}
+
+// WithStreaming follows story lists and threads with server-sent events so
+// they change live, instead of fetching them once.
+func WithStreaming() HNOption {
+	return func(h *HN) {
+		h.streaming = true
+	}
+}
+
+func NewHN(opts ...HNOption) *HN {
 	baseURL, err := url.Parse("https://hacker-news.firebaseio.com/v0")
 	if err != nil {
 		panic(err)
 	}
 
-	return &HN{baseURL: baseURL}
+	searchURL, err := url.Parse("https://hn.algolia.com/api/v1")
+	if err != nil {
+		panic(err)
+	}
+
+	h := HN{
+		baseURL:   baseURL,
+		searchURL: searchURL,
+		client:    http.DefaultClient,
+		userAgent: "termhnal",
+		workers:   8,
+		retry:     DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(&h)
+	}
+
+	h.scheduler = NewScheduler(h.workers, h.fetch)
+	return &h
+}
+
+// Close stops the client's workers. Requests still waiting for one fail
+// with ErrClosed.
+func (h *HN) Close() {
+	h.scheduler.Close()
+}
+
+// Offline reports whether the client is restricted to cached responses.
+func (h *HN) Offline() bool {
+	return h.offline && h.cache != nil
 }
 
-func (h *HN) Top() ([]int, error) {
-	return h.items("top")
+// Streaming reports whether lists and threads should be streamed.
+func (h *HN) Streaming() bool {
+	return h.streaming && !h.Offline()
 }
 
-func (h *HN) New() ([]int, error) {
-	return h.items("new")
+// Progress reports the state of outstanding requests.
+func (h *HN) Progress() Progress {
+	return h.scheduler.Progress()
 }
 
-func (h *HN) Best() ([]int, error) {
-	return h.items("best")
+// Prioritize moves queued requests for ids ahead of everything else, e.g.
+// because they have just become visible.
+func (h *HN) Prioritize(ids ...int) {
+	for _, id := range ids {
+		h.scheduler.Promote(itemPath(id), PriorityHigh)
+	}
 }
 
-func (h *HN) Ask() ([]int, error) {
-	return h.items("ask")
+func (h *HN) Top(ctx context.Context) ([]int, error) {
+	return h.items(ctx, "top")
 }
 
-func (h *HN) Show() ([]int, error) {
-	return h.items("show")
+func (h *HN) New(ctx context.Context) ([]int, error) {
+	return h.items(ctx, "new")
 }
 
-func (h *HN) Job() ([]int, error) {
-	return h.items("job")
+func (h *HN) Best(ctx context.Context) ([]int, error) {
+	return h.items(ctx, "best")
 }
 
-func (h *HN) items(kind string) ([]int, error) {
-	requestURL := h.baseURL.JoinPath(fmt.Sprintf("/%sstories.json", kind))
-	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, requestURL.String(), nil)
+func (h *HN) Ask(ctx context.Context) ([]int, error) {
+	return h.items(ctx, "ask")
+}
+
+func (h *HN) Show(ctx context.Context) ([]int, error) {
+	return h.items(ctx, "show")
+}
+
+func (h *HN) Job(ctx context.Context) ([]int, error) {
+	return h.items(ctx, "job")
+}
+
+// fetch gets path, retrying transient failures, and caches the response.
+func (h *HN) fetch(ctx context.Context, path string) ([]byte, error) {
+	body, err := h.fetchURL(ctx, h.baseURL.JoinPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	if h.cache != nil {
+		// a broken cache only costs performance
+		_ = h.cache.Put(path, body)
+	}
+
+	return body, nil
+}
+
+// fetchURL gets requestURL, retrying transient failures.
+func (h *HN) fetchURL(ctx context.Context, requestURL *url.URL) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := h.fetchOnce(ctx, requestURL)
+		if err == nil {
+			return body, nil
+		} else if attempt >= h.retry.Retries || !transient(ctx, err) {
+			return nil, err
+		}
+
+		delay := h.retry.Backoff(attempt)
+		var status *StatusError
+		if errors.As(err, &status) && status.RetryAfter > delay {
+			delay = status.RetryAfter
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (h *HN) fetchOnce(ctx context.Context, requestURL *url.URL) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := http.DefaultClient.Do(request)
+	if h.userAgent != "" {
+		request.Header.Set("User-Agent", h.userAgent)
+	}
+
+	response, err := h.client.Do(request)
 	if err != nil {
 		return nil, err
 	}
 	defer response.Body.Close()
 
-	var stories []int
-	if err := json.NewDecoder(response.Body).Decode(&stories); err != nil {
+	if response.StatusCode != http.StatusOK {
+		status := StatusError{URL: requestURL.String(), StatusCode: response.StatusCode}
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+			status.RetryAfter = time.Duration(seconds) * time.Second
+		}
+
+		return nil, &status
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
 		return nil, err
 	}
 
-	return stories, nil
+	if !json.Valid(body) {
+		return nil, &DecodeError{URL: requestURL.String(), Err: errors.New("invalid JSON")}
+	}
+
+	return body, nil
 }
 
-func (h *HN) item(id int, item any) error {
-	requestURL := h.baseURL.JoinPath(fmt.Sprintf("/item/%d.json", id))
-	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, requestURL.String(), nil)
-	if err != nil {
-		return err
+func (h *HN) get(ctx context.Context, path string, v any) error {
+	if h.cache != nil {
+		if body, stored, ok := h.cache.Get(path); ok && (h.offline || time.Since(stored) < TTL(path, body)) {
+			return decode(path, body, v)
+		} else if h.offline {
+			return ErrUnavailable
+		}
 	}
 
-	response, err := http.DefaultClient.Do(request)
+	body, err := h.scheduler.Do(ctx, path)
 	if err != nil {
 		return err
 	}
-	defer response.Body.Close()
 
-	return json.NewDecoder(response.Body).Decode(item)
+	return decode(path, body, v)
 }
 
-func (h *HN) Story(rank, id int) (*Story, error) {
+func decode(path string, body []byte, v any) error {
+	if bytes.Equal(bytes.TrimSpace(body), []byte("null")) {
+		// the API returns null for items which do not exist
+		return fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return &DecodeError{URL: path, Err: err}
+	}
+
+	return nil
+}
+
+func itemPath(id int) string {
+	return fmt.Sprintf("/item/%d.json", id)
+}
+
+func userPath(id string) string {
+	return fmt.Sprintf("/user/%s.json", url.PathEscape(id))
+}
+
+func (h *HN) items(ctx context.Context, kind string) ([]int, error) {
+	var stories []int
+	if err := h.get(ctx, fmt.Sprintf("/%sstories.json", kind), &stories); err != nil {
+		return nil, err
+	}
+
+	return stories, nil
+}
+
+func (h *HN) item(ctx context.Context, id int, item any) error {
+	return h.get(ctx, itemPath(id), item)
+}
+
+func (h *HN) Story(ctx context.Context, rank, id int) (*Story, error) {
 	story := NewStory(rank)
-	if err := h.item(id, story); err != nil {
+	if err := h.item(ctx, id, story); err != nil {
 		return nil, err
 	}
 
+	story.loaded = true
 	return story, nil
 }
 
-func (h *HN) Comment(rank, id int) (*Comment, error) {
+func (h *HN) PollOpt(ctx context.Context, rank, id int) (*PollOpt, error) {
+	option := NewPollOpt(rank)
+	if err := h.item(ctx, id, &option); err != nil {
+		return nil, err
+	}
+
+	return option, nil
+}
+
+// Options fetches every option of the poll story. Failed options are added
+// as placeholders and their errors returned together.
+func (h *HN) Options(ctx context.Context, story *Story) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for i := range story.Parts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			option, err := h.PollOpt(ctx, i, story.Parts[i])
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+
+				if ctx.Err() != nil {
+					return
+				}
+
+				option = NewPollOpt(i)
+				option.ID = story.Parts[i]
+				option.err = err
+			}
+
+			story.AddOption(option)
+		}(i)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (h *HN) User(ctx context.Context, id string) (*User, error) {
+	var user User
+	if err := h.get(ctx, userPath(id), &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (h *HN) Comment(ctx context.Context, rank, id int) (*Comment, error) {
 	comment := NewComment(rank)
-	if err := h.item(id, &comment); err != nil {
+	if err := h.item(ctx, id, &comment); err != nil {
 		return nil, err
 	}
 
 	return comment, nil
 }
+
+// Walk fetches the comment tree below parent, up to depth levels deep or
+// all of it if depth is negative, adding each comment to its parent. fn, if
+// not nil, is called as each comment completes, with a nil comment if it
+// failed. Failed comments are added as placeholders, without their replies,
+// and their errors returned together.
+func (h *HN) Walk(ctx context.Context, parent *Item, depth int, fn func(*Comment, error)) error {
+	if depth == 0 {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for i := range parent.Kids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			comment, err := h.Comment(ctx, i, parent.Kids[i])
+			if fn != nil {
+				fn(comment, err)
+			}
+
+			if err == nil {
+				parent.AddComment(comment)
+				err = h.Walk(ctx, comment.Item, depth-1, fn)
+			} else if ctx.Err() == nil {
+				placeholder := NewComment(i)
+				placeholder.ID = parent.Kids[i]
+				placeholder.Parent = parent.ID
+				placeholder.err = err
+				parent.AddComment(placeholder)
+			}
+
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Thread fetches the item id as a story, with its poll options and every
+// comment below it. Options and comments which fail to load are left in the
+// thread as placeholders and counted in failed.
+func (h *HN) Thread(ctx context.Context, id int) (story *Story, failed int, err error) {
+	story, err = h.Story(ctx, 0, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// failed options are counted below
+	h.Options(ctx, story)
+
+	var n atomic.Int32
+	h.Walk(ctx, story.Item, -1, func(_ *Comment, err error) {
+		if err != nil {
+			n.Add(1)
+		}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	failed = int(n.Load())
+	for _, option := range story.Options {
+		if option.err != nil {
+			failed++
+		}
+	}
+
+	return story, failed, nil
+}
+
+// Updates are the items and profiles which changed recently.
+type Updates struct {
+	Items    []int    `json:"items"`
+	Profiles []string `json:"profiles"`
+}
+
+func (h *HN) Updates(ctx context.Context) (*Updates, error) {
+	var updates Updates
+	if err := h.get(ctx, "/updates.json", &updates); err != nil {
+		return nil, err
+	}
+
+	return &updates, nil
+}
+
+// Watch polls for updates every interval until ctx is done. Cached copies
+// of changed items are expired before each batch of updates is sent on the
+// returned channel. Failed polls are skipped; the next one catches up.
+func (h *HN) Watch(ctx context.Context, interval time.Duration) <-chan *Updates {
+	ch := make(chan *Updates)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			updates, err := h.Updates(ctx)
+			if err != nil {
+				continue
+			}
+
+			if h.cache != nil {
+				for _, id := range updates.Items {
+					_ = h.cache.Expire(itemPath(id))
+				}
+
+				for _, id := range updates.Profiles {
+					_ = h.cache.Expire(userPath(id))
+				}
+			}
+
+			select {
+			case ch <- updates:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
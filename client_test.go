@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newTestHN is NewHN, closed when the test ends.
+func newTestHN(t *testing.T, opts ...HNOption) *HN {
+	t.Helper()
+
+	hn := NewHN(opts...)
+	t.Cleanup(hn.Close)
+	return hn
+}
+
+// roundTripFunc is an http.RoundTripper made from a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNewHNDefaults(t *testing.T) {
+	hn := newTestHN(t)
+
+	if got, want := hn.baseURL.String(), "https://hacker-news.firebaseio.com/v0"; got != want {
+		t.Errorf("baseURL = %s, want %s", got, want)
+	}
+
+	if hn.client != http.DefaultClient {
+		t.Errorf("client = %v, want http.DefaultClient", hn.client)
+	}
+
+	if hn.userAgent != "termhnal" {
+		t.Errorf("userAgent = %q, want termhnal", hn.userAgent)
+	}
+}
+
+func TestNewHNOptions(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		fmt.Fprint(w, `{"id":1,"type":"story","by":"pg","title":"mirrored"}`)
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL + "/mirror/v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var roundTrips int
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		roundTrips++
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	hn := newTestHN(t, WithBaseURL(base), WithHTTPClient(client), WithUserAgent("mirror-bot"))
+	story, err := hn.Story(context.Background(), 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if story.Item.Title != "mirrored" {
+		t.Errorf("title = %q, want the mirror's story", story.Item.Title)
+	}
+
+	if roundTrips != 1 {
+		t.Errorf("client made %d round trips, want 1", roundTrips)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("server got %d requests, want 1", len(requests))
+	}
+
+	if got, want := requests[0].URL.Path, "/mirror/v0/item/1.json"; got != want {
+		t.Errorf("path = %s, want %s", got, want)
+	}
+
+	if got := requests[0].Header.Get("User-Agent"); got != "mirror-bot" {
+		t.Errorf("User-Agent = %q, want mirror-bot", got)
+	}
+}
+
+func TestStoryCanceled(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"id":1,"type":"story"}`)
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := newTestHN(t, WithBaseURL(base)).Story(ctx, 0, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+
+	if requests != 0 {
+		t.Errorf("server got %d requests, want none", requests)
+	}
+}
+
+func TestStoryRetries(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprint(w, `{"id":1,"type":"story","title":"second try"}`)
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	story, err := newTestHN(t, WithBaseURL(base), WithRetry(RetryPolicy{Retries: 1})).Story(context.Background(), 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if story.Item.Title != "second try" || requests != 2 {
+		t.Errorf("title = %q after %d requests, want the second try", story.Item.Title, requests)
+	}
+}
+
+func TestStoryErrors(t *testing.T) {
+	var decodeError *DecodeError
+	for _, tt := range []struct {
+		name     string
+		status   int
+		body     string
+		is       func(error) bool
+		requests int
+	}{
+		{"not found", http.StatusNotFound, "", func(err error) bool { return errors.Is(err, ErrNotFound) }, 1},
+		{"rate limited", http.StatusTooManyRequests, "", func(err error) bool { return errors.Is(err, ErrRateLimited) }, 3},
+		{"server error", http.StatusBadGateway, "", func(err error) bool { return errors.Is(err, ErrServer) }, 3},
+		{"invalid json", http.StatusOK, "<html>", func(err error) bool { return errors.As(err, &decodeError) }, 1},
+		{"wrong type", http.StatusOK, `{"id":"1"}`, func(err error) bool { return errors.As(err, &decodeError) }, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			base, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = newTestHN(t, WithBaseURL(base), WithRetry(RetryPolicy{Retries: 2})).Story(context.Background(), 0, 1)
+			if !tt.is(err) {
+				t.Errorf("err = %v, want %s", err, tt.name)
+			}
+
+			if requests != tt.requests {
+				t.Errorf("server got %d requests, want %d", requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestUser(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		fmt.Fprint(w, `{"id":"pg","created":1160418092,"karma":155111,"about":"Bug fixer.","submitted":[3,2,1]}`)
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := newTestHN(t, WithBaseURL(base)).User(context.Background(), "pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/user/pg.json" {
+		t.Errorf("path = %s, want /user/pg.json", path)
+	}
+
+	if user.ID != "pg" || user.Created != 1160418092 || user.Karma != 155111 || user.About != "Bug fixer." || len(user.Submitted) != 3 {
+		t.Errorf("user = %+v", user)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	items := map[string]string{
+		"/item/7.json": `{"id":7,"type":"pollopt","poll":1,"text":"yes","score":3}`,
+		"/item/8.json": `{"id":8,"type":"pollopt","poll":1,"text":"no","score":1}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := items[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	story := NewStory(0)
+	story.ID, story.Parts = 1, []int{8, 9, 7}
+
+	if err := newTestHN(t, WithBaseURL(base)).Options(context.Background(), story); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want %v for 9", err, ErrNotFound)
+	}
+
+	if len(story.Options) != 3 {
+		t.Fatalf("story has %d options, want 3", len(story.Options))
+	}
+
+	if o := story.Options[0]; o.Rank != 0 || o.Text != "no" || o.Score != 1 || o.Poll != 1 {
+		t.Errorf("first option = %+v, want no", o)
+	}
+
+	if o := story.Options[1]; o.Rank != 1 || o.ID != 9 || !errors.Is(o.err, ErrNotFound) {
+		t.Errorf("second option = %+v, want a placeholder for 9", o)
+	}
+
+	if o := story.Options[2]; o.Rank != 2 || o.Text != "yes" || o.Score != 3 {
+		t.Errorf("third option = %+v, want yes", o)
+	}
+}
+
+func TestThreadPartial(t *testing.T) {
+	items := map[string]string{
+		"/item/1.json": `{"id":1,"type":"poll","by":"pg","title":"poll","kids":[2,3],"parts":[7,8]}`,
+		"/item/2.json": `{"id":2,"type":"comment","by":"bob","parent":1,"text":"kept","kids":[4]}`,
+		"/item/3.json": `null`,
+		"/item/4.json": `{"id":4,"type":"comment","by":"eve","parent":2,"text":"reply"}`,
+		"/item/7.json": `{"id":7,"type":"pollopt","poll":1,"text":"yes","score":3}`,
+		"/item/8.json": `null`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, items[r.URL.Path])
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	story, failed, err := newTestHN(t, WithBaseURL(base)).Thread(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failed != 2 {
+		t.Errorf("failed = %d, want a comment and an option", failed)
+	}
+
+	if len(story.Options) != 2 || story.Options[0].Text != "yes" || story.Options[1].ID != 8 || story.Options[1].err == nil {
+		t.Errorf("options = %+v, want yes and a placeholder for 8", story.Options)
+	}
+
+	if len(story.Comments) != 2 {
+		t.Fatalf("story has %d comments, want 2", len(story.Comments))
+	}
+
+	if c := story.Comments[0]; c.ID != 2 || c.err != nil || len(c.Comments) != 1 {
+		t.Errorf("first comment = %+v, want 2 with its reply", c.Item)
+	}
+
+	if c := story.Comments[1]; c.ID != 3 || c.Parent != 1 || c.placeholder() != "[missing]" {
+		t.Errorf("second comment = %+v, want a placeholder for 3", c.Item)
+	}
+}
+
+func TestThreadCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/item/1.json" {
+			fmt.Fprint(w, `{"id":1,"type":"story","kids":[2]}`)
+			return
+		}
+
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := newTestHN(t, WithBaseURL(base)).Thread(ctx, 1); err != context.Canceled {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	bbt "github.com/charmbracelet/bubbletea"
+)
+
+// Clipboard copies text to the clipboard.
+type Clipboard struct {
+	output io.Writer
+	local  func(string) error
+
+	// remote is set over SSH, where the local clipboard is the wrong one
+	remote bool
+
+	// tmux and screen pass OSC 52 on only if it is wrapped for them
+	tmux, screen bool
+}
+
+// NewClipboard copies text by writing OSC 52 to output, the terminal, and
+// with local, which defaults to the system clipboard.
+func NewClipboard(output io.Writer, local func(string) error) *Clipboard {
+	if output == nil {
+		output = os.Stdout
+	}
+
+	if local == nil {
+		local = clipboard.WriteAll
+	}
+
+	return &Clipboard{
+		output: output,
+		local:  local,
+		remote: os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "",
+		tmux:   os.Getenv("TMUX") != "",
+		screen: strings.HasPrefix(os.Getenv("TERM"), "screen"),
+	}
+}
+
+// Copy copies text through the terminal with OSC 52, which reaches the
+// clipboard of the machine the terminal runs on even over SSH or from a
+// container. Terminals may ignore OSC 52, so outside of SSH the local
+// clipboard is set as well.
+func (c *Clipboard) Copy(text string) error {
+	seq := osc52.New(text)
+	if c.tmux {
+		seq = seq.Tmux()
+	} else if c.screen {
+		seq = seq.Screen()
+	}
+
+	_, err := seq.WriteTo(c.output)
+	if c.remote {
+		return err
+	}
+
+	if localErr := c.local(text); localErr != nil && err != nil {
+		return errors.Join(err, localErr)
+	}
+
+	return nil
+}
+
+// Yank copies text with clipboard, reporting failures as an ErrorMsg.
+func Yank(clipboard *Clipboard, text string) bbt.Cmd {
+	return func() bbt.Msg {
+		if text == "" {
+			return ErrorMsg{Err: errors.New("nothing to copy"), Time: time.Now()}
+		}
+
+		if err := clipboard.Copy(text); err != nil {
+			return ErrorMsg{Err: fmt.Errorf("copy: %w", err), Time: time.Now()}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	bbt "github.com/charmbracelet/bubbletea"
+	"golang.org/x/net/html"
+)
+
+// exportFormats are the formats stories are exported to, with the
+// extension of their files.
+var exportFormats = map[string]string{
+	"md":   ".md",
+	"json": ".json",
+	"html": ".html",
+}
+
+// exportItem is a story or comment as it is exported, with its replies.
+type exportItem struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"`
+	By          string    `json:"by,omitempty"`
+	Time        time.Time `json:"time"`
+	Permalink   string    `json:"permalink"`
+	Title       string    `json:"title,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Score       int       `json:"score,omitempty"`
+	Descendants int       `json:"descendants,omitempty"`
+	Dead        bool      `json:"dead,omitempty"`
+	Deleted     bool      `json:"deleted,omitempty"`
+
+	// Error is why a comment failed to load. Placeholder stands in for
+	// it, or for the title of a dead story
+	Error       string `json:"error,omitempty"`
+	Placeholder string `json:"-"`
+
+	// Text is the item's text without markup and HTML is the text as HN
+	// serves it
+	Text string `json:"text,omitempty"`
+	HTML string `json:"html,omitempty"`
+
+	Options  []exportOption `json:"options,omitempty"`
+	Comments []*exportItem  `json:"comments,omitempty"`
+}
+
+// exportOption is an option of a poll.
+type exportOption struct {
+	Text  string `json:"text"`
+	Score int    `json:"score"`
+
+	// Error is why the option failed to load, and Text its placeholder
+	Error string `json:"error,omitempty"`
+}
+
+// newExportItem copies story and the comments loaded below it, leaving out
+// the text of dead items, and the title and link of a dead story, unless
+// showDead is set.
+func newExportItem(story *Story, showDead bool) *exportItem {
+	story.mu.RLock()
+	item := exportItem{
+		ID:          story.ID,
+		Type:        story.Type,
+		By:          story.By,
+		Time:        time.Unix(story.Time, 0).UTC(),
+		Permalink:   ItemURL(story.ID),
+		Score:       story.Score,
+		Descendants: story.Descendants,
+		Dead:        story.Dead,
+		Deleted:     story.Deleted,
+	}
+
+	if !story.Dead || showDead {
+		item.Title, item.URL = story.Item.Title, story.URL
+		item.Text, item.HTML = HTMLText(story.Text), story.Text
+	} else {
+		item.Placeholder = "[dead]"
+	}
+
+	for _, option := range story.Options {
+		if option.err != nil {
+			item.Options = append(item.Options, exportOption{Text: option.placeholder(), Error: option.err.Error()})
+			continue
+		}
+
+		item.Options = append(item.Options, exportOption{Text: HTMLText(option.Text), Score: option.Score})
+	}
+
+	comments := slices.Clone(story.Comments)
+	story.mu.RUnlock()
+
+	var thread func([]*Comment) []*exportItem
+	thread = func(comments []*Comment) []*exportItem {
+		var items []*exportItem
+		for _, c := range comments {
+			c.mu.RLock()
+			item := exportItem{
+				ID:        c.ID,
+				Type:      c.Type,
+				By:        c.By,
+				Time:      time.Unix(c.Time, 0).UTC(),
+				Permalink: ItemURL(c.ID),
+				Dead:      c.Dead,
+				Deleted:   c.Deleted,
+			}
+
+			if c.err != nil {
+				item.Error, item.Placeholder = c.err.Error(), c.placeholder()
+			} else if !c.Deleted && (!c.Dead || showDead) {
+				item.Text, item.HTML = HTMLText(c.Text), c.Text
+			}
+
+			replies := slices.Clone(c.Comments)
+			c.mu.RUnlock()
+
+			item.Comments = thread(replies)
+			items = append(items, &item)
+		}
+
+		return items
+	}
+
+	item.Comments = thread(comments)
+	return &item
+}
+
+// Export writes story and its comments to w in format, one of md, json or
+// html.
+func Export(w io.Writer, story *Story, format string, showDead bool) error {
+	switch format {
+	case "md":
+		return WriteMarkdown(w, story, showDead)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newExportItem(story, showDead))
+	case "html":
+		return exportTemplate.Execute(w, newExportItem(story, showDead))
+	}
+
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// DefaultExportDir returns the directory stories are exported to,
+// $XDG_DOWNLOAD_DIR or else the user's home directory.
+func DefaultExportDir() (string, error) {
+	if dir := os.Getenv("XDG_DOWNLOAD_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	return os.UserHomeDir()
+}
+
+// ExportFile exports story to a new file named after it in dir, or
+// DefaultExportDir if dir is empty, returning its absolute path. Earlier
+// exports are kept by numbering the name, e.g. termhnal-1-2.md.
+func ExportFile(dir string, story *Story, format string, showDead bool) (string, error) {
+	ext, ok := exportFormats[format]
+	if !ok {
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+
+	if dir == "" {
+		var err error
+		if dir, err = DefaultExportDir(); err != nil {
+			return "", err
+		}
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var name string
+	var f *os.File
+	for n := 1; ; n++ {
+		name = filepath.Join(dir, fmt.Sprintf("termhnal-%d%s", story.ID, ext))
+		if n > 1 {
+			name = filepath.Join(dir, fmt.Sprintf("termhnal-%d-%d%s", story.ID, n, ext))
+		}
+
+		f, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !errors.Is(err, fs.ErrExist) {
+			break
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := Export(f, story, format, showDead); err != nil {
+		return "", err
+	}
+
+	return name, f.Close()
+}
+
+// ExportMsg reports the file a story was exported to.
+type ExportMsg struct {
+	Value string
+
+	// Failed counts the comments which failed to load and were exported
+	// as placeholders.
+	Failed int
+}
+
+// ExportStory fetches the story id with all of its comments and exports it
+// to a file in dir in format, reporting failures as an ErrorMsg.
+func ExportStory(hn *HN, id int, dir, format string, showDead bool) bbt.Cmd {
+	var cmd bbt.Cmd
+	cmd = func() bbt.Msg {
+		story, failed, err := hn.Thread(context.Background(), id)
+		if err != nil {
+			return ErrorMsg{Err: fmt.Errorf("export: %w", err), Time: time.Now(), Retry: cmd}
+		}
+
+		name, err := ExportFile(dir, story, format, showDead)
+		if err != nil {
+			return ErrorMsg{Err: fmt.Errorf("export: %w", err), Time: time.Now(), Retry: cmd}
+		}
+
+		return ExportMsg{Value: name, Failed: failed}
+	}
+
+	return cmd
+}
+
+// exportTags are the elements kept in exported HTML. Everything else HN
+// allows in text is one of these.
+var exportTags = map[string]bool{
+	"p":      true,
+	"i":      true,
+	"em":     true,
+	"b":      true,
+	"strong": true,
+	"pre":    true,
+	"code":   true,
+	"br":     true,
+}
+
+// cleanHTML returns text from HN with only the markup HN allows, so
+// nothing in it runs when the export is opened.
+func cleanHTML(text string) template.HTML {
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	r := newHTMLRenderer(hnURL, false, nil)
+
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(template.HTMLEscapeString(n.Data))
+			return
+		} else if n.Type == html.ElementNode {
+			if exportTags[n.Data] {
+				fmt.Fprintf(&sb, "<%s>", n.Data)
+				if n.Data != "br" {
+					defer fmt.Fprintf(&sb, "</%s>", n.Data)
+				}
+			} else if n.Data == "a" {
+				if link := r.resolve(attr(n, "href")); link != "" {
+					fmt.Fprintf(&sb, `<a href="%s" rel="nofollow">`, template.HTMLEscapeString(link))
+					defer sb.WriteString("</a>")
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(root)
+	return template.HTML(sb.String())
+}
+
+var exportTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"clean": cleanHTML,
+	"user":  UserURL,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{or .Placeholder .Title}}</title>
+<style>
+body { max-width: 50em; margin: 2em auto; padding: 0 1em; font-family: sans-serif; line-height: 1.4; }
+.meta { color: #828282; font-size: 0.9em; }
+.meta a { color: inherit; }
+ul.comments { list-style: none; padding-left: 1.5em; border-left: 1px solid #e0e0e0; }
+pre { overflow-x: auto; background: #f6f6ef; padding: 0.5em; }
+</style>
+</head>
+<body>
+<h1>{{if .URL}}<a href="{{.URL}}">{{.Title}}</a>{{else}}{{or .Placeholder .Title}}{{end}}</h1>
+<p class="meta">{{.Score}} points by <a href="{{user .By}}">{{.By}}</a> at <a href="{{.Permalink}}"><time datetime="{{.Time.Format "2006-01-02T15:04:05Z07:00"}}">{{.Time.Format "2006-01-02 15:04 MST"}}</time></a> | {{.Descendants}} comments</p>
+{{with .HTML}}<div>{{clean .}}</div>{{end}}
+{{with .Options}}<ul>{{range .}}<li>{{.Text}}{{if not .Error}} ({{.Score}} points){{end}}</li>{{end}}</ul>{{end}}
+{{template "comments" .Comments}}
+</body>
+</html>
+{{define "comments"}}{{if .}}<ul class="comments">{{range .}}
+<li>{{if .Placeholder}}<p class="meta">{{.Placeholder}}</p>{{else if .Deleted}}<p class="meta">[deleted]</p>{{else}}<p class="meta"><a href="{{user .By}}">{{.By}}</a> at <a href="{{.Permalink}}"><time datetime="{{.Time.Format "2006-01-02T15:04:05Z07:00"}}">{{.Time.Format "2006-01-02 15:04 MST"}}</time></a>{{if .Dead}} [dead]{{end}}</p>
+{{with .HTML}}<div>{{clean .}}</div>{{end}}{{end}}
+{{template "comments" .Comments}}</li>{{end}}
+</ul>{{end}}{{end}}`))
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: termhnal export <id> [flags]")
+		fmt.Fprintln(fs.Output(), "Write a story and all of its comments as Markdown, JSON or HTML.")
+		fs.PrintDefaults()
+	}
+
+	flags := NewClientFlags(fs)
+	format := fs.String("format", "md", "format to write: md, json or html")
+	output := fs.String("o", "", "file to write to (default stdout)")
+	showDead := fs.Bool("show-dead", false, "include the text of dead stories and comments")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// flags may also follow the id, e.g. termhnal export 1 -format json
+	rest := fs.Args()
+	if len(rest) > 0 {
+		if err := fs.Parse(rest[1:]); err != nil {
+			return err
+		}
+
+		rest = append([]string{rest[0]}, fs.Args()...)
+	}
+
+	if len(rest) != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	id, err := strconv.Atoi(rest[0])
+	if err != nil || id < 1 {
+		return fmt.Errorf("invalid id %q", rest[0])
+	}
+
+	if _, ok := exportFormats[*format]; !ok {
+		return fmt.Errorf("invalid -format %q: must be md, json or html", *format)
+	}
+
+	hn, err := flags.HN()
+	if err != nil {
+		return err
+	}
+	defer hn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	story, failed, err := hn.Thread(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d comments failed to load and were exported as placeholders\n", failed)
+	}
+
+	if *output == "" {
+		return Export(os.Stdout, story, *format, *showDead)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := Export(f, story, *format, *showDead); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
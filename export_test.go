@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// newExportStory is a thread with every kind of comment an export handles.
+func newExportStory() *Story {
+	story := NewStory(0)
+	story.ID, story.Type, story.By, story.Time = 1, "story", "pg", 1700000000
+	story.Item.Title, story.Text, story.Score, story.Descendants = "Ask HN: Exporting threads?", "How do you <i>share</i> a thread?", 42, 5
+	story.URL, story.Dead = "https://example.com/export_(thread)", true
+	story.Kids, story.loaded = []int{2, 3, 5}, true
+
+	option := NewPollOpt(0)
+	option.ID, option.Text, option.Score = 7, "Markdown", 3
+	story.AddOption(option)
+
+	failedOption := NewPollOpt(1)
+	failedOption.ID, failedOption.err = 8, fmt.Errorf("/item/8.json: %w", ErrNotFound)
+	story.AddOption(failedOption)
+
+	comment := func(rank, id, parent int, by, text string) *Comment {
+		c := NewComment(rank)
+		c.ID, c.Parent, c.Type, c.By, c.Text, c.Time = id, parent, "comment", by, text, 1700000000+int64(id)*60
+		return c
+	}
+
+	kept := comment(0, 2, 1, "bob", `Markdown works <a href="https://example.com">well</a>.<p>It keeps *stars* escaped.`+
+		"<p>Code like <code>a `b` c</code> and <code>`tick</code> keeps its backticks, "+
+		`and links like <a href="https://en.wikipedia.org/wiki/Go_(game)">Go (game)</a> or <a href="https://example.com/a b">this</a> stay whole.`)
+	kept.Kids = []int{4, 6}
+
+	dead := comment(0, 4, 2, "spam", "buy now")
+	dead.Dead = true
+	kept.AddComment(dead)
+	kept.AddComment(comment(1, 6, 2, "eve", "<pre><code>  indented\n```\nfenced\n```\n</code></pre>"))
+
+	failed := NewComment(1)
+	failed.ID, failed.Parent, failed.err = 3, 1, fmt.Errorf("/item/3.json: %w", ErrNotFound)
+
+	deleted := comment(2, 5, 1, "", "")
+	deleted.Deleted = true
+
+	story.AddComment(kept)
+	story.AddComment(failed)
+	story.AddComment(deleted)
+	return story
+}
+
+func TestExportGolden(t *testing.T) {
+	for format, ext := range exportFormats {
+		for _, showDead := range []bool{false, true} {
+			name := "export" + ext
+			if showDead {
+				name = "export-dead" + ext
+			}
+
+			t.Run(name, func(t *testing.T) {
+				var b bytes.Buffer
+				if err := Export(&b, newExportStory(), format, showDead); err != nil {
+					t.Fatal(err)
+				}
+
+				golden := filepath.Join("testdata", name)
+				if *update {
+					if err := os.WriteFile(golden, b.Bytes(), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got := b.String(); got != string(want) {
+					t.Errorf("Export() = %s\nwant %s", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestExportFile(t *testing.T) {
+	story := NewStory(0)
+	story.ID, story.By, story.Item.Title, story.loaded = 1, "pg", "story", true
+
+	downloads := t.TempDir()
+	t.Setenv("XDG_DOWNLOAD_DIR", downloads)
+
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		dir, want string
+	}{
+		{dir, filepath.Join(dir, "termhnal-1.md")},
+		{"", filepath.Join(downloads, "termhnal-1.md")},
+	} {
+		name, err := ExportFile(tt.dir, story, "md", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if name != tt.want {
+			t.Errorf("ExportFile(%q) = %q, want %q", tt.dir, name, tt.want)
+		}
+
+		if _, err := os.Stat(name); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// earlier exports are kept
+	name, err := ExportFile(dir, story, "md", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(dir, "termhnal-1-2.md"); name != want {
+		t.Errorf("ExportFile() again = %q, want %q", name, want)
+	}
+}
+
+func TestRunExportUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"1", "2"}} {
+		if err := runExport(args); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("runExport(%q) = %v, want %v", args, err, flag.ErrHelp)
+		}
+	}
+}
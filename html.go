@@ -0,0 +1,514 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+	"golang.org/x/net/html"
+)
+
+// skippedElements never hold text worth reading.
+var skippedElements = map[string]bool{
+	"head":     true,
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"canvas":   true,
+	"iframe":   true,
+	"object":   true,
+	"form":     true,
+	"button":   true,
+	"input":    true,
+	"select":   true,
+	"textarea": true,
+	"nav":      true,
+}
+
+// blockElements start and end a paragraph.
+var blockElements = map[string]bool{
+	"html":       true,
+	"body":       true,
+	"p":          true,
+	"div":        true,
+	"section":    true,
+	"article":    true,
+	"main":       true,
+	"header":     true,
+	"footer":     true,
+	"aside":      true,
+	"figure":     true,
+	"figcaption": true,
+	"address":    true,
+	"details":    true,
+	"summary":    true,
+	"dl":         true,
+	"dt":         true,
+	"dd":         true,
+	"li":         true,
+	"table":      true,
+	"caption":    true,
+	"tr":         true,
+	"center":     true,
+}
+
+// htmlRenderer renders HTML as styled text wrapped for the terminal.
+type htmlRenderer struct {
+	// base resolves relative links
+	base *url.URL
+
+	// footnotes numbers links in the text and collects their targets in
+	// footnoted, rather than showing them inline
+	footnotes bool
+	footnoted []string
+
+	// links marks linked text as hyperlinks, if enabled
+	links *Hyperlinks
+
+	// quotes reads paragraphs starting with > as quotes, the convention
+	// for quoting on HN
+	quotes bool
+
+	// plain leaves out styles, for text which is not shown in the
+	// terminal, e.g. when it is copied
+	plain bool
+
+	// styleText is the style of text without markup
+	styleText    lipgloss.Style
+	styleHeading lipgloss.Style
+	styleCode    lipgloss.Style
+	styleQuote   lipgloss.Style
+	styleFaint   lipgloss.Style
+}
+
+func newHTMLRenderer(base *url.URL, footnotes bool, links *Hyperlinks) *htmlRenderer {
+	return &htmlRenderer{
+		base:         base,
+		footnotes:    footnotes,
+		links:        links,
+		styleText:    lipgloss.NewStyle(),
+		styleHeading: lipgloss.NewStyle().Foreground(lipgloss.Color("#ff6600")).Bold(true),
+		styleCode:    lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#5f5faf", Dark: "#afafff"}),
+		styleQuote:   lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}),
+		styleFaint:   lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}),
+	}
+}
+
+// hnURL resolves relative links in text from HN, e.g. item?id=1.
+var hnURL, _ = url.Parse(webURL + "/")
+
+// RenderHTML renders text from HN, e.g. a comment, in style wrapped at
+// width, with markup such as italics, links and code blocks styled. Links
+// are hyperlinks if links is enabled. Widths below one do not wrap.
+func RenderHTML(text string, width int, style lipgloss.Style, links *Hyperlinks) string {
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		// show the text as it is rather than nothing
+		return fit(style.Render(text), width)
+	}
+
+	r := newHTMLRenderer(hnURL, false, links)
+	r.styleText = style
+	r.quotes = true
+	return r.Render(root, width)
+}
+
+// HTMLText returns text from HN without any markup, with a blank line
+// between paragraphs. Links are followed by their targets, and those HN
+// shortened are written out in full.
+func HTMLText(text string) string {
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return text
+	}
+
+	r := newHTMLRenderer(hnURL, false, nil)
+	r.plain = true
+
+	// keep the indent of code starting the text
+	return strings.Trim(r.Render(root, 0), "\n")
+}
+
+// styled renders s in style, unless the renderer writes plain text.
+func (r *htmlRenderer) styled(style lipgloss.Style, s string) string {
+	if r.plain {
+		return s
+	}
+
+	return style.Render(s)
+}
+
+// Render renders the children of n wrapped at width, with a blank line
+// between paragraphs.
+func (r *htmlRenderer) Render(n *html.Node, width int) string {
+	return strings.Join(r.blocks(n, width), "\n\n")
+}
+
+// blocks renders the children of n as paragraphs, lists, code blocks and
+// quotes, each wrapped at width.
+func (r *htmlRenderer) blocks(n *html.Node, width int) []string {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, c)
+	}
+
+	return r.nodes(children, width)
+}
+
+// nodes renders each of nodes in turn. See blocks.
+func (r *htmlRenderer) nodes(nodes []*html.Node, width int) []string {
+	var blocks []string
+	var sb strings.Builder
+
+	// space is set after whitespace, so runs of it collapse to one space
+	space := true
+
+	// quote is the depth of a paragraph starting with >, if quotes is set
+	var quote int
+
+	// href is the target of the link being written, if any
+	var href string
+
+	flush := func() {
+		if text := strings.TrimRightFunc(sb.String(), unicode.IsSpace); strings.TrimSpace(text) != "" {
+			if quote > 0 {
+				bar := strings.Repeat(r.styled(r.styleQuote, "│ "), quote)
+				text = fit(text, width-lipgloss.Width(bar))
+				text = bar + strings.ReplaceAll(text, "\n", "\n"+bar)
+			}
+
+			blocks = append(blocks, fit(text, width))
+		}
+
+		sb.Reset()
+		space = true
+		quote = 0
+	}
+
+	write := func(s string, style lipgloss.Style) {
+		if sb.Len() == 0 {
+			s = strings.TrimLeftFunc(s, unicode.IsSpace)
+			for r.quotes && strings.HasPrefix(s, ">") {
+				s = strings.TrimLeftFunc(s[1:], unicode.IsSpace)
+				quote++
+			}
+		}
+
+		words := strings.Fields(s)
+		if len(words) == 0 {
+			if s != "" && !space {
+				sb.WriteByte(' ')
+				space = true
+			}
+
+			return
+		}
+
+		if first, _ := utf8.DecodeRuneInString(s); unicode.IsSpace(first) && !space {
+			sb.WriteByte(' ')
+		}
+
+		// style words separately so wrapping never carries a style onto
+		// the next line's indent
+		for i, word := range words {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+
+			sb.WriteString(r.links.Link(href, r.styled(style, word)))
+		}
+
+		space = false
+		if last, _ := utf8.DecodeLastRuneInString(s); unicode.IsSpace(last) {
+			sb.WriteByte(' ')
+			space = true
+		}
+	}
+
+	var walk func(*html.Node, lipgloss.Style)
+	walk = func(n *html.Node, style lipgloss.Style) {
+		switch n.Type {
+		case html.TextNode:
+			write(n.Data, style)
+			return
+		case html.ElementNode:
+			if skippedElements[n.Data] {
+				return
+			}
+
+			switch n.Data {
+			case "br":
+				sb.WriteByte('\n')
+				space = true
+				return
+			case "hr":
+				flush()
+				rule := width
+				if rule < 1 {
+					rule = 3
+				}
+
+				blocks = append(blocks, r.styled(r.styleFaint, strings.Repeat("─", rule)))
+				return
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				flush()
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c, r.styleHeading)
+				}
+
+				flush()
+				return
+			case "pre":
+				flush()
+				if text := r.pre(n, width); text != "" {
+					blocks = append(blocks, text)
+				}
+
+				return
+			case "blockquote":
+				flush()
+				if text := r.quote(n, width); text != "" {
+					blocks = append(blocks, text)
+				}
+
+				return
+			case "ul", "ol":
+				flush()
+				if text := r.list(n, width); text != "" {
+					blocks = append(blocks, text)
+				}
+
+				return
+			case "img":
+				if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+					write(" ", style)
+					write(fmt.Sprintf("[image: %s]", alt), r.styleFaint)
+					write(" ", style)
+				}
+
+				return
+			case "a":
+				link := r.resolve(attr(n, "href"))
+				if link == "" {
+					break
+				}
+
+				if r.links.Enabled() {
+					// the text itself is the link
+					outer := href
+					href = link
+					for c := n.FirstChild; c != nil; c = c.NextSibling {
+						walk(c, style.Copy().Underline(true))
+					}
+
+					href = outer
+					if r.footnotes {
+						r.footnoted = append(r.footnoted, link)
+						write(fmt.Sprintf("[%d]", len(r.footnoted)), r.styleFaint)
+					}
+
+					return
+				}
+
+				text := strings.TrimSpace(textContent(n))
+				if !r.footnotes && (text == link || strings.HasSuffix(text, "...") && strings.HasPrefix(link, strings.TrimSuffix(text, "..."))) {
+					// HN truncates long links and appends "...", so show
+					// the whole link instead
+					write(link, style.Copy().Underline(true))
+					return
+				}
+
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c, style.Copy().Underline(true))
+				}
+
+				if r.footnotes {
+					r.footnoted = append(r.footnoted, link)
+					write(fmt.Sprintf("[%d]", len(r.footnoted)), r.styleFaint)
+				} else {
+					write(" ", style)
+					write(fmt.Sprintf("(%s)", link), r.styleFaint)
+				}
+
+				return
+			case "i", "em", "cite", "var":
+				style = style.Copy().Italic(true)
+			case "b", "strong":
+				style = style.Copy().Bold(true)
+			case "u", "ins":
+				style = style.Copy().Underline(true)
+			case "s", "del", "strike":
+				style = style.Copy().Strikethrough(true)
+			case "code", "kbd", "samp", "tt":
+				style = r.styleCode.Copy().Inherit(style)
+			case "td", "th":
+				write(" ", style)
+			default:
+				if blockElements[n.Data] {
+					flush()
+					defer flush()
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, style)
+		}
+	}
+
+	for _, n := range nodes {
+		walk(n, r.styleText)
+	}
+
+	flush()
+	return blocks
+}
+
+// pre renders preformatted text as it is, cutting off lines wider than
+// width rather than wrapping them.
+func (r *htmlRenderer) pre(n *html.Node, width int) string {
+	text := strings.ReplaceAll(textContent(n), "\t", "    ")
+	text = strings.TrimPrefix(text, "\n")
+	text = strings.TrimRightFunc(text, unicode.IsSpace)
+	if text == "" {
+		return ""
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if width > 0 {
+			line = truncate.StringWithTail(line, uint(width), "…")
+		}
+
+		lines[i] = r.styled(r.styleCode, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// quote renders a blockquote behind a bar.
+func (r *htmlRenderer) quote(n *html.Node, width int) string {
+	bar := r.styled(r.styleQuote, "│ ")
+	blocks := r.blocks(n, width-lipgloss.Width(bar))
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	lines := strings.Split(strings.Join(blocks, "\n\n"), "\n")
+	for i, line := range lines {
+		lines[i] = bar + line
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// list renders the items of a ul or ol, each behind a bullet or number,
+// with nested lists indented below them.
+func (r *htmlRenderer) list(n *html.Node, width int) string {
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+
+	var items []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			continue
+		}
+
+		marker := "• "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		indent := strings.Repeat(" ", lipgloss.Width(marker))
+		lines := strings.Split(strings.Join(r.blocks(c, width-len(indent)), "\n"), "\n")
+		for i, line := range lines {
+			if i == 0 {
+				lines[i] = r.styled(r.styleFaint, marker) + line
+			} else if line != "" {
+				lines[i] = indent + line
+			}
+		}
+
+		items = append(items, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// resolve returns the absolute URL of a link, or nothing for links which
+// cannot be followed outside the page, e.g. fragments and scripts.
+func (r *htmlRenderer) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	if r.base != nil {
+		u = r.base.ResolveReference(u)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return u.String()
+	}
+
+	return ""
+}
+
+// fit wraps s at width, breaking words which are wider than it, e.g. long
+// links. Widths below one do not wrap.
+func fit(s string, width int) string {
+	if width < 1 {
+		return s
+	}
+
+	return splitLinks(wrap.String(wordwrap.String(s, width), width))
+}
+
+// attr returns the value of the attribute key of n.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+// textContent returns the text below n without any markup.
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		} else if n.Type == html.ElementNode && n.Data == "br" {
+			sb.WriteByte('\n')
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(n)
+	return sb.String()
+}
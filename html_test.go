@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/ansi"
+)
+
+// trueColor renders styles as they are in a color terminal until the test
+// ends. Profile 0 is termenv.TrueColor.
+func trueColor(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(0)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+}
+
+// plain strips styles from s.
+func plain(s string) string {
+	return styles.ReplaceAllString(s, "")
+}
+
+func TestRenderHTML(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"entities are decoded once", "&lt;b&gt; &amp;amp; &#x27;q&#x27;", 0, "<b> &amp; 'q'"},
+		{"paragraphs", "one<p>two<p>three", 0, "one\n\ntwo\n\nthree"},
+		{"whitespace collapses", "a  \n b", 0, "a b"},
+		{"quote", "<p>&gt; quoted\n<p>reply", 0, "│ quoted\n\nreply"},
+		{"nested quote", "&gt;&gt; deeper", 0, "│ │ deeper"},
+		{"wrapping", "the quick brown fox jumps over the lazy dog", 16, "the quick brown\nfox jumps over\nthe lazy dog"},
+		{"quotes wrap inside the bar", "&gt; the quick brown fox jumps", 14, "│ the quick\n│ brown fox\n│ jumps"},
+		{"code keeps whitespace", "<pre><code>  if x {\n      y()\n  }\n</code></pre>", 0, "  if x {\n      y()\n  }"},
+		{"code is cut off rather than wrapped", "<pre><code>0123456789 0123456789\n  ok</code></pre>", 12, "0123456789 …\n  ok"},
+		{"shortened link", `<a href="https://example.com/a/long/path">https://example.com/a/lo...</a>`, 0, "https://example.com/a/long/path"},
+		{"link with text", `see <a href="https://example.com">this</a>.`, 0, "see this (https://example.com)."},
+		{"relative link", `<a href="item?id=1">item</a>`, 0, "item (https://news.ycombinator.com/item?id=1)"},
+		{"script link", `<a href="javascript:alert(1)">x</a>`, 0, "x"},
+		{"long words break", "https://example.com/a/very/long/path", 12, "https://exam\nple.com/a/ve\nry/long/path"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := plain(RenderHTML(tt.text, tt.width, lipgloss.NewStyle(), nil))
+			if got != tt.want {
+				t.Errorf("RenderHTML(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+
+			if tt.width > 0 {
+				for _, line := range strings.Split(got, "\n") {
+					if w := ansi.PrintableRuneWidth(line); w > tt.width {
+						t.Errorf("line %q is %d wide, want at most %d", line, w, tt.width)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderHTMLStyles(t *testing.T) {
+	trueColor(t)
+
+	got := RenderHTML("<i>italic</i> <b>bold</b> <code>code</code>", 0, lipgloss.NewStyle(), nil)
+	for _, want := range []string{
+		lipgloss.NewStyle().Italic(true).Render("italic"),
+		lipgloss.NewStyle().Bold(true).Render("bold"),
+		newHTMLRenderer(nil, false, nil).styleCode.Render("code"),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderHTML() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestRenderHTMLMalformed(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"<p><i>unclosed",
+		"</pre></code>stray",
+		"<pre><code>",
+		"&gt;",
+		"<a href=\"%zz\">bad escape</a>",
+		"<<<>>>",
+		"\x00\xff",
+	} {
+		// must not panic
+		RenderHTML(text, 20, lipgloss.NewStyle(), nil)
+		HTMLText(text)
+	}
+}
+
+func TestHTMLText(t *testing.T) {
+	trueColor(t)
+
+	for _, tt := range []struct {
+		text, want string
+	}{
+		{"<i>no</i> <b>styles</b>", "no styles"},
+		{"one<p>two", "one\n\ntwo"},
+		{"&gt; quotes are kept", "> quotes are kept"},
+		{`<a href="https://example.com/a/long/path">https://example.com/a/lo...</a>`, "https://example.com/a/long/path"},
+		{`see <a href="https://example.com/x">here</a> for more`, "see here (https://example.com/x) for more"},
+		{`<a href="https://example.com/x">https://example.com/x</a>`, "https://example.com/x"},
+		{"<pre><code>  indented\n    code</code></pre>", "  indented\n    code"},
+	} {
+		if got := HTMLText(tt.text); got != tt.want {
+			t.Errorf("HTMLText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -2,39 +2,140 @@ package main
 
 import (
 	"cmp"
+	"errors"
 	"fmt"
 	"net/url"
 	"slices"
 	"strings"
 	"sync"
 	"time"
-
-	"golang.org/x/net/html"
 )
 
 type Item struct {
 	Rank int
 
-	By    string `json:"by"`
-	ID    int    `json:"id"`
-	Kids  []int  `json:"kids"`
-	Time  int64  `json:"time"`
-	Title string `json:"title"`
-	Type  string `json:"type"`
+	By      string `json:"by"`
+	Dead    bool   `json:"dead"`
+	Deleted bool   `json:"deleted"`
+	ID      int    `json:"id"`
+	Kids    []int  `json:"kids"`
+	Time    int64  `json:"time"`
+	Title   string `json:"title"`
+	Type    string `json:"type"`
 
 	Comments []*Comment
 
+	// err is set on placeholders for items which failed to load
+	err error
+
 	mu sync.RWMutex
 }
 
+// AddComment adds c to the item's comments, replacing any comment with the
+// same ID, e.g. a placeholder which is being retried. The item's kids may
+// have changed while c loaded, so c is re-ranked to match them, or dropped
+// if it is no longer among them.
 func (i *Item) AddComment(c *Comment) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	i.Comments = append(i.Comments, c)
-	slices.SortFunc(i.Comments, func(i, j *Comment) int {
-		return cmp.Compare(i.Rank, j.Rank)
+	if len(i.Kids) > 0 {
+		rank := slices.Index(i.Kids, c.ID)
+		if rank < 0 {
+			return
+		}
+
+		c.Rank = rank
+	}
+
+	i.Comments = slices.DeleteFunc(i.Comments, func(e *Comment) bool {
+		return e.ID == c.ID
 	})
+
+	// comments arrive in any order, so insert in place rather than sort
+	j, _ := slices.BinarySearchFunc(i.Comments, c.Rank, func(e *Comment, rank int) int {
+		return cmp.Compare(e.Rank, rank)
+	})
+
+	i.Comments = slices.Insert(i.Comments, j, c)
+}
+
+// merge copies the fields of a newer copy of the item, keeping comments
+// already loaded. Comments are reordered to follow the new kids and those
+// no longer among them are dropped. It returns the ranks of kids which
+// have not been loaded yet.
+func (i *Item) merge(newer *Item) (added []int) {
+	i.mu.Lock()
+	i.By, i.Dead, i.Deleted, i.Time, i.Title = newer.By, newer.Dead, newer.Deleted, newer.Time, newer.Title
+	i.mu.Unlock()
+
+	return i.setKids(newer.Kids)
+}
+
+// setKids replaces Kids, re-ranking loaded comments and dropping those no
+// longer present. Kids still loading are re-ranked as they arrive. It
+// returns the ranks of kids which are new.
+func (i *Item) setKids(kids []int) (added []int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	loaded := make(map[int]*Comment, len(i.Comments))
+	for _, c := range i.Comments {
+		loaded[c.ID] = c
+	}
+
+	comments := make([]*Comment, 0, len(kids))
+	for rank, id := range kids {
+		if c, ok := loaded[id]; ok {
+			c.Rank = rank
+			comments = append(comments, c)
+		} else if !slices.Contains(i.Kids, id) {
+			added = append(added, rank)
+		}
+	}
+
+	i.Kids = kids
+	i.Comments = comments
+	return added
+}
+
+// find returns the comment id anywhere below the item.
+func (i *Item) find(id int) *Comment {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, c := range i.Comments {
+		if c.ID == id {
+			return c
+		} else if found := c.find(id); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// walk calls fn for every comment below the item, parents first.
+func (i *Item) walk(fn func(*Comment)) {
+	i.mu.RLock()
+	comments := slices.Clone(i.Comments)
+	i.mu.RUnlock()
+
+	for _, c := range comments {
+		fn(c)
+		c.walk(fn)
+	}
+}
+
+// placeholder describes an item which failed to load.
+func (i *Item) placeholder() string {
+	if errors.Is(i.err, ErrUnavailable) {
+		return "[unavailable offline]"
+	} else if errors.Is(i.err, ErrNotFound) {
+		return "[missing]"
+	}
+
+	return "[failed to load]"
 }
 
 func humanize(t time.Time) string {
@@ -51,61 +152,6 @@ func humanize(t time.Time) string {
 	}
 }
 
-func HTMLText(t string) string {
-	root, err := html.Parse(strings.NewReader(html.UnescapeString(t)))
-	if err != nil {
-		panic(err)
-	}
-
-	var sb strings.Builder
-
-	type state struct {
-		element    string
-		attributes map[string]string
-	}
-
-	var fn func(state, *html.Node)
-	fn = func(s state, n *html.Node) {
-		switch n.Type {
-		case html.TextNode:
-			text := n.Data
-			switch s.element {
-			case "a":
-				if val, ok := s.attributes["href"]; ok {
-					text = fmt.Sprintf("(%s %s)", n.Data, val)
-					if n.Data == val {
-						text = val
-					} else if trim := strings.TrimSuffix(n.Data, "..."); strings.HasPrefix(val, trim) {
-						// HN truncates long links and appends "..."
-						text = val
-					}
-				}
-			}
-
-			sb.WriteString(text)
-		case html.ElementNode:
-			switch n.Data {
-			case "a":
-				s.element = n.Data
-				s.attributes = make(map[string]string)
-				for _, attr := range n.Attr {
-					// discard Namespace
-					s.attributes[attr.Key] = attr.Val
-				}
-			case "p":
-				sb.WriteString("\n\n")
-			}
-		}
-
-		for child := n.FirstChild; child != nil; child = child.NextSibling {
-			fn(s, child)
-		}
-	}
-
-	fn(state{}, root)
-	return sb.String()
-}
-
 type Story struct {
 	*Item
 
@@ -113,6 +159,13 @@ type Story struct {
 	Score       int    `json:"score"`
 	Text        string `json:"text"`
 	URL         string `json:"url"`
+
+	// Parts are the options of a poll
+	Parts   []int `json:"parts"`
+	Options []*PollOpt
+
+	// loaded is false for placeholders which have not been fetched yet
+	loaded bool
 }
 
 func NewStory(Rank int) *Story {
@@ -123,13 +176,72 @@ func NewStory(Rank int) *Story {
 	}
 }
 
+// AddOption adds o to the poll's options, replacing any option with the
+// same rank.
+func (s *Story) AddOption(o *PollOpt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.Options {
+		if s.Options[i].Rank == o.Rank {
+			s.Options[i] = o
+			return
+		}
+	}
+
+	s.Options = append(s.Options, o)
+	slices.SortFunc(s.Options, func(i, j *PollOpt) int {
+		return cmp.Compare(i.Rank, j.Rank)
+	})
+}
+
+// Merge updates the story from a newer copy. See Item.merge.
+func (s *Story) Merge(newer *Story) (added []int) {
+	s.mu.Lock()
+	s.Descendants, s.Score, s.Text, s.URL = newer.Descendants, newer.Score, newer.Text, newer.URL
+	s.mu.Unlock()
+
+	return s.merge(newer.Item)
+}
+
+// Link is the story's URL, or its discussion page if it has none, e.g. Ask
+// HN.
+func (s *Story) Link() string {
+	if s.URL != "" {
+		return s.URL
+	}
+
+	return ItemURL(s.ID)
+}
+
 func (s Story) FilterValue() string {
 	return s.Title()
 }
 
 func (s Story) Title() string {
+	if s.err != nil {
+		return fmt.Sprintf("%d. %s", s.Rank+1, s.placeholder())
+	} else if !s.loaded {
+		return fmt.Sprintf("%d. loading...", s.Rank+1)
+	} else if s.Deleted {
+		return fmt.Sprintf("%d. [deleted]", s.Rank+1)
+	}
+
+	if s.Type == "comment" {
+		// comments have no title so show the start of their text
+		text := strings.Join(strings.Fields(HTMLText(s.Text)), " ")
+		if runes := []rune(text); len(runes) > 80 {
+			text = string(runes[:77]) + "..."
+		}
+
+		return fmt.Sprintf("%d. %s", s.Rank+1, text)
+	}
+
 	var sb strings.Builder
 	fmt.Fprintf(&sb, "%d. %s", s.Rank+1, s.Item.Title)
+	if s.Dead {
+		sb.WriteString(" [dead]")
+	}
 
 	if s.URL != "" {
 		link, err := url.Parse(s.URL)
@@ -154,6 +266,20 @@ func (s Story) Description() string {
 		prefix = strings.Repeat(" ", 5)
 	}
 
+	if s.err != nil {
+		if errors.Is(s.err, ErrUnavailable) || errors.Is(s.err, ErrNotFound) {
+			return prefix
+		}
+
+		return fmt.Sprintf("%s%s | r to retry", prefix, s.err)
+	} else if !s.loaded || s.Deleted {
+		return prefix
+	}
+
+	if s.Type == "comment" {
+		return fmt.Sprintf("%sby %s %s | %d replies", prefix, s.By, humanize(time.Unix(s.Time, 0)), len(s.Kids))
+	}
+
 	return fmt.Sprintf("%s%d points by %s %s | %d comments", prefix, s.Score, s.By, humanize(time.Unix(s.Time, 0)), s.Descendants)
 }
 
@@ -162,6 +288,18 @@ type Comment struct {
 
 	Parent int    `json:"parent"`
 	Text   string `json:"text"`
+
+	// fresh marks comments which arrived after the story was opened
+	fresh bool
+}
+
+// Merge updates the comment from a newer copy. See Item.merge.
+func (c *Comment) Merge(newer *Comment) (added []int) {
+	c.mu.Lock()
+	c.Text = newer.Text
+	c.mu.Unlock()
+
+	return c.merge(newer.Item)
 }
 
 func NewComment(rank int) *Comment {
@@ -171,3 +309,27 @@ func NewComment(rank int) *Comment {
 		},
 	}
 }
+
+type PollOpt struct {
+	*Item
+
+	Poll  int    `json:"poll"`
+	Score int    `json:"score"`
+	Text  string `json:"text"`
+}
+
+func NewPollOpt(rank int) *PollOpt {
+	return &PollOpt{
+		Item: &Item{
+			Rank: rank,
+		},
+	}
+}
+
+type User struct {
+	ID        string `json:"id"`
+	Created   int64  `json:"created"`
+	Karma     int    `json:"karma"`
+	About     string `json:"about"`
+	Submitted []int  `json:"submitted"`
+}
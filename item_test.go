@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAddCommentReplacesReranked(t *testing.T) {
+	item := &Item{ID: 1}
+	for rank, id := range []int{10, 20} {
+		c := NewComment(rank)
+		c.ID = id
+		item.AddComment(c)
+	}
+
+	// live updates moved comment 10 below comment 20
+	item.setKids([]int{20, 10})
+
+	c := NewComment(1)
+	c.ID = 10
+	item.AddComment(c)
+
+	if len(item.Comments) != 2 {
+		t.Fatalf("got %d comments, want 2", len(item.Comments))
+	}
+
+	for i, want := range []int{20, 10} {
+		if got := item.Comments[i].ID; got != want {
+			t.Errorf("Comments[%d].ID = %d, want %d", i, got, want)
+		}
+	}
+
+	if item.Comments[1] != c {
+		t.Error("comment 10 was not replaced")
+	}
+}
+
+func TestAddCommentOrdersByRank(t *testing.T) {
+	item := &Item{ID: 1}
+	for _, rank := range []int{2, 0, 1} {
+		c := NewComment(rank)
+		c.ID = 100 + rank
+		item.AddComment(c)
+	}
+
+	for i, c := range item.Comments {
+		if c.Rank != i {
+			t.Errorf("Comments[%d].Rank = %d, want %d", i, c.Rank, i)
+		}
+	}
+}
+
+func TestSetKidsWhileLoading(t *testing.T) {
+	item := &Item{ID: 1, Kids: []int{10, 20, 30}}
+
+	// 10 has loaded while 20 and 30 are still being fetched
+	loaded := NewComment(0)
+	loaded.ID = 10
+	item.AddComment(loaded)
+
+	// a new kid arrives at the top, 30 moves above 20 and 10 is gone
+	added := item.setKids([]int{40, 30, 20})
+	if len(added) != 1 || added[0] != 0 {
+		t.Errorf("added = %v, want [0] for the new kid only", added)
+	}
+
+	// the fetches started before the update finish with their old ranks
+	for _, kid := range []struct{ rank, id int }{{2, 30}, {1, 20}, {0, 40}, {0, 10}} {
+		c := NewComment(kid.rank)
+		c.ID = kid.id
+		item.AddComment(c)
+	}
+
+	var ids []int
+	for rank, c := range item.Comments {
+		ids = append(ids, c.ID)
+		if c.Rank != rank {
+			t.Errorf("comment %d has rank %d, want %d", c.ID, c.Rank, rank)
+		}
+	}
+
+	if want := []int{40, 30, 20}; !slices.Equal(ids, want) {
+		t.Errorf("comments = %v, want %v", ids, want)
+	}
+}
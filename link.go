@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/muesli/reflow/ansi"
+)
+
+// Hyperlinks makes text clickable in terminals which support OSC 8.
+//
+// Text is marked with placeholders rather than real hyperlinks until
+// Resolve, since lipgloss and reflow measure escape sequences only up to
+// their first letter and would count a hyperlink's URL as text.
+type Hyperlinks struct {
+	enabled bool
+
+	mu   sync.Mutex
+	urls []string
+	ids  map[string]int
+}
+
+// NewHyperlinks returns Hyperlinks which marks links only if enabled, so
+// text is left as it is for terminals without OSC 8.
+func NewHyperlinks(enabled bool) *Hyperlinks {
+	return &Hyperlinks{
+		enabled: enabled,
+		ids:     make(map[string]int),
+	}
+}
+
+// HyperlinksSupported guesses from the environment whether the terminal
+// supports OSC 8. FORCE_HYPERLINK overrides the guess.
+func HyperlinksSupported(getenv func(string) string) bool {
+	if force := getenv("FORCE_HYPERLINK"); force != "" {
+		enabled, _ := strconv.ParseBool(force)
+		return enabled
+	}
+
+	term := getenv("TERM")
+	if term == "" || term == "dumb" {
+		return false
+	} else if getenv("TMUX") != "" || strings.HasPrefix(term, "screen") {
+		// multiplexers drop hyperlinks unless configured to pass them on
+		return false
+	}
+
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby", "rio":
+		return true
+	}
+
+	if vte, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		// e.g. GNOME Terminal and Tilix
+		return true
+	}
+
+	for _, key := range []string{"KITTY_WINDOW_ID", "WT_SESSION", "KONSOLE_VERSION", "DOMTERM"} {
+		if getenv(key) != "" {
+			return true
+		}
+	}
+
+	for _, name := range []string{"kitty", "foot", "alacritty", "wezterm", "ghostty", "contour"} {
+		if strings.Contains(term, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Enabled reports whether text is marked as links.
+func (h *Hyperlinks) Enabled() bool {
+	return h != nil && h.enabled
+}
+
+// linkOpen and linkClose mark where a link starts and ends. Terminals
+// ignore the sequence and reflow measures it as zero width.
+const (
+	linkOpen  = "\x1b[9;%dz"
+	linkClose = "\x1b[9z"
+)
+
+var linkMarks = regexp.MustCompile(`\x1b\[9(?:;(\d+))?z`)
+
+// styles matches SGR sequences, which may come between two parts of a link.
+var styles = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// Link marks each line of text as a link to target, leaving trailing
+// padding outside of it. Text is returned as it is if links are disabled
+// or target is not a valid URL.
+func (h *Hyperlinks) Link(target, text string) string {
+	if !h.Enabled() {
+		return text
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || strings.IndexFunc(target, func(r rune) bool { return r < ' ' || r == 0x7f }) >= 0 {
+		return text
+	}
+
+	h.mu.Lock()
+	id, ok := h.ids[u.String()]
+	if !ok {
+		h.urls = append(h.urls, u.String())
+		id = len(h.urls)
+		h.ids[u.String()] = id
+	}
+	h.mu.Unlock()
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if trimmed := strings.TrimRight(line, " "); trimmed != "" {
+			lines[i] = fmt.Sprintf(linkOpen, id) + trimmed + linkClose + line[len(trimmed):]
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// splitLinks closes links which a line break cuts in two, e.g. a long link
+// wrapped mid-word, and opens them again on the next line, so each line's
+// links are resolved on their own.
+func splitLinks(s string) string {
+	if !strings.Contains(s, "\x1b[9") {
+		return s
+	}
+
+	var open string
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = open + line
+		open = ""
+		if marks := linkMarks.FindAllStringSubmatchIndex(line, -1); len(marks) > 0 {
+			if last := marks[len(marks)-1]; last[2] >= 0 {
+				open = line[last[0]:last[1]]
+				line += linkClose
+			}
+		}
+
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Resolve replaces marked links in view with OSC 8 hyperlinks. Terminals
+// measure a hyperlink's URL as zero width but bubbletea, which cuts off
+// lines wider than the terminal, does not, so links which would make a
+// line seem wider than width are left as plain text.
+func (h *Hyperlinks) Resolve(view string, width int) string {
+	if !h.Enabled() || !strings.Contains(view, "\x1b[9") {
+		return view
+	}
+
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		if linkMarks.MatchString(line) {
+			lines[i] = h.resolve(line, width)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// resolve replaces marked links in line, keeping as many as fit in width
+// from the left.
+func (h *Hyperlinks) resolve(line string, width int) string {
+	type link struct {
+		// open and close are the indexes of the link's marks in marks
+		open, close int
+		url         string
+	}
+
+	marks := linkMarks.FindAllStringSubmatchIndex(line, -1)
+
+	h.mu.Lock()
+	var links []link
+	for i, m := range marks {
+		if m[2] < 0 {
+			// close the link which is open, if any
+			if n := len(links); n > 0 && links[n-1].close < 0 {
+				links[n-1].close = i
+			}
+
+			continue
+		}
+
+		id, _ := strconv.Atoi(line[m[2]:m[3]])
+		if id < 1 || id > len(h.urls) {
+			continue
+		}
+
+		if n := len(links); n > 0 && links[n-1].close >= 0 && links[n-1].url == h.urls[id-1] {
+			// words of the same link, e.g. separated by a space, are
+			// joined into one hyperlink
+			if gap := line[marks[links[n-1].close][1]:m[0]]; strings.TrimSpace(styles.ReplaceAllString(gap, "")) == "" {
+				links[n-1].close = -1
+				continue
+			}
+		}
+
+		links = append(links, link{open: i, close: -1, url: h.urls[id-1]})
+	}
+	h.mu.Unlock()
+
+	render := func(keep int) string {
+		replace := make(map[int]string)
+		for _, l := range links[:keep] {
+			replace[l.open] = "\x1b]8;;" + l.url + "\x1b\\"
+			if l.close >= 0 {
+				replace[l.close] = "\x1b]8;;\x1b\\"
+			}
+		}
+
+		var sb strings.Builder
+		var last int
+		for i, m := range marks {
+			sb.WriteString(line[last:m[0]])
+			sb.WriteString(replace[i])
+			last = m[1]
+		}
+
+		sb.WriteString(line[last:])
+		if keep > 0 && links[keep-1].close < 0 {
+			// a link left open would run on into the next line
+			sb.WriteString("\x1b]8;;\x1b\\")
+		}
+
+		// lines are cleared before they are drawn, so trailing padding
+		// only takes up room for links
+		return strings.TrimRight(sb.String(), " ")
+	}
+
+	for keep := len(links); keep > 0; keep-- {
+		if s := render(keep); width <= 0 || ansi.PrintableRuneWidth(s) <= width {
+			return s
+		}
+	}
+
+	return render(0)
+}
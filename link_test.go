@@ -0,0 +1,109 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/ansi"
+)
+
+// osc8 matches an OSC 8 hyperlink, capturing its URL and text.
+var osc8 = regexp.MustCompile(`\x1b\]8;;([^\x1b]+)\x1b\\(.*?)\x1b\]8;;\x1b\\`)
+
+func TestResolveWrappedLink(t *testing.T) {
+	const link = "https://example.com/a/very/long/path/which/wraps"
+
+	links := NewHyperlinks(true)
+	text := RenderHTML(`see <a href="`+link+`">`+link+`</a> here`, 20, lipgloss.NewStyle(), links)
+
+	// no width, so no link is dropped for seeming too wide
+	lines := strings.Split(links.Resolve(text, 0), "\n")
+
+	var linked []string
+	for _, line := range lines {
+		if strings.Contains(line, "\x1b[9") {
+			t.Errorf("line %q has unresolved marks", line)
+		}
+
+		for _, m := range osc8.FindAllStringSubmatch(line, -1) {
+			if m[1] != link {
+				t.Errorf("line %q links to %q, want %q", line, m[1], link)
+			}
+
+			linked = append(linked, plain(m[2]))
+		}
+	}
+
+	// every fragment of the link is clickable
+	if got := strings.Join(linked, ""); got != link {
+		t.Errorf("linked text = %q, want %q", got, link)
+	}
+}
+
+func TestResolveWidth(t *testing.T) {
+	links := NewHyperlinks(true)
+	line := links.Link("https://example.com/one", "one") + " " + links.Link("https://example.com/two", "two")
+
+	if got := links.Resolve(line, 80); len(osc8.FindAllString(got, -1)) != 2 {
+		t.Errorf("Resolve() at 80 = %q, want both links", got)
+	}
+
+	// links which would make the line seem too wide are left as text
+	got := links.Resolve(line, 30)
+	if ansi.PrintableRuneWidth(got) > 30 {
+		t.Errorf("Resolve() at 30 = %q, wider than 30", got)
+	}
+
+	if plain(osc8.ReplaceAllString(got, "$2")) != "one two" {
+		t.Errorf("Resolve() at 30 = %q, want the text kept", got)
+	}
+}
+
+func TestResolveMergesWords(t *testing.T) {
+	links := NewHyperlinks(true)
+	line := links.Link("https://example.com", "two") + " " + links.Link("https://example.com", "words")
+
+	if got := osc8.FindAllStringSubmatch(links.Resolve(line, 0), -1); len(got) != 1 || got[0][2] != "two words" {
+		t.Errorf("Resolve() = %q, want one link around both words", got)
+	}
+}
+
+func TestLinkDisabled(t *testing.T) {
+	for _, links := range []*Hyperlinks{nil, NewHyperlinks(false)} {
+		if got := links.Link("https://example.com", "text"); got != "text" {
+			t.Errorf("Link() = %q, want the text as it is", got)
+		}
+	}
+
+	links := NewHyperlinks(true)
+	for _, target := range []string{"not a url", "https://example.com/\x1b]8;;evil"} {
+		if got := links.Link(target, "text"); got != "text" {
+			t.Errorf("Link(%q) = %q, want the text as it is", target, got)
+		}
+	}
+}
+
+func TestHyperlinksSupported(t *testing.T) {
+	for _, tt := range []struct {
+		env  map[string]string
+		want bool
+	}{
+		{map[string]string{}, false},
+		{map[string]string{"TERM": "dumb", "TERM_PROGRAM": "iTerm.app"}, false},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "iTerm.app"}, true},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "iTerm.app", "TMUX": "/tmp/tmux"}, false},
+		{map[string]string{"TERM": "xterm-256color", "VTE_VERSION": "6800"}, true},
+		{map[string]string{"TERM": "xterm-256color", "VTE_VERSION": "4000"}, false},
+		{map[string]string{"TERM": "xterm-kitty"}, true},
+		{map[string]string{"TERM": "xterm-256color"}, false},
+		{map[string]string{"FORCE_HYPERLINK": "1"}, true},
+		{map[string]string{"FORCE_HYPERLINK": "0", "TERM": "xterm-kitty"}, false},
+	} {
+		env := tt.env
+		if got := HyperlinksSupported(func(key string) string { return env[key] }); got != tt.want {
+			t.Errorf("HyperlinksSupported(%v) = %t, want %t", env, got, tt.want)
+		}
+	}
+}
@@ -1,19 +1,96 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
 	bbt "github.com/charmbracelet/bubbletea"
 )
 
 type Model struct {
 	list   *WindowList
 	view   *WindowView
+	errors *WindowErrors
+	user   *WindowUser
+	search *WindowSearch
+	reader *WindowReader
 	active Window
+
+	// lists maps lists outside the front page to the window showing them
+	lists map[*PaneList]Window
+
+	hn      *HN
+	config  *Config
+	updates <-chan *Updates
+
+	// history holds the windows to return to on Activate("back")
+	history []Window
+
+	// width is the width of the terminal
+	width int
+
+	log *ErrorLog
 }
 
-func NewModel() *Model {
+// Config holds display preferences shared by every window.
+type Config struct {
+	// ShowDead shows the text of dead stories and comments.
+	ShowDead bool
+
+	// Updates is how often to poll for live updates. Zero disables them.
+	Updates time.Duration
+
+	// Opener opens links in an external browser.
+	Opener *Opener
+
+	// Hyperlinks makes links clickable in terminals which support it.
+	Hyperlinks *Hyperlinks
+
+	// Clipboard copies links and text.
+	Clipboard *Clipboard
+
+	// ExportDir is where stories are exported to, DefaultExportDir if
+	// empty.
+	ExportDir string
+}
+
+func NewModel(hn *HN, config *Config) *Model {
+	if config.Opener == nil {
+		config.Opener = NewOpener("", nil)
+	}
+
+	if config.Hyperlinks == nil {
+		config.Hyperlinks = NewHyperlinks(false)
+	}
+
+	if config.Clipboard == nil {
+		config.Clipboard = NewClipboard(nil, nil)
+	}
+
+	log := &ErrorLog{}
 	model := Model{
-		list: NewWindowList(),
-		view: NewWindowView(),
+		list:   NewWindowList(hn, log, config),
+		view:   NewWindowView(hn, log, config),
+		errors: NewWindowErrors(hn, log),
+		user:   NewWindowUser(hn, log, config),
+		search: NewWindowSearch(hn, log, config),
+		reader: NewWindowReader(hn, log, config),
+		log:    log,
+		hn:     hn,
+		config: config,
+	}
+
+	model.lists = map[*PaneList]Window{
+		model.user.user.list:     model.user,
+		model.search.search.list: model.search,
 	}
 
 	model.active = model.list
@@ -21,32 +98,127 @@ func NewModel() *Model {
 }
 
 func (m *Model) Init() bbt.Cmd {
-	return bbt.Sequence(
-		Activate("list"),
-		List("top"),
-	)
+	cmds := []bbt.Cmd{
+		bbt.Sequence(
+			Activate("list"),
+			List("top"),
+		),
+	}
+
+	if m.config.Updates > 0 && !m.hn.Offline() {
+		m.updates = m.hn.Watch(context.Background(), m.config.Updates)
+		cmds = append(cmds, m.waitUpdates)
+	}
+
+	return bbt.Batch(cmds...)
+}
+
+// waitUpdates waits for the next batch of live updates.
+func (m *Model) waitUpdates() bbt.Msg {
+	updates, ok := <-m.updates
+	if !ok {
+		return nil
+	}
+
+	return UpdatesMsg{Value: updates}
 }
 
 func (m *Model) Update(msg bbt.Msg) (bbt.Model, bbt.Cmd) {
 	switch msg := msg.(type) {
 	case bbt.KeyMsg:
 		switch msg.String() {
-		case "q", "ctrl+c":
+		case "q":
+			// q is text in the search input
+			if m.active != m.search || !m.search.search.Typing() {
+				return m, nil
+			}
+		case "ctrl+c":
 			// mask off ctrl+c
 			return m, nil
 		case "ctrl+d":
 			return m, bbt.Quit
 		}
 	case ActivateMsg:
+		var next Window
 		switch msg {
 		case "list":
+			// the list is the root of every window
+			m.history = nil
 			m.active = m.list
 		case "view":
-			m.active = m.view
+			next = m.view
+		case "errors":
+			next = m.errors
+		case "user":
+			next = m.user
+		case "search":
+			next = m.search
+		case "reader":
+			next = m.reader
+		case "back":
+			if n := len(m.history); n > 0 {
+				m.active, m.history = m.history[n-1], m.history[:n-1]
+			} else {
+				m.active = m.list
+			}
+		}
+
+		if next != nil && next != m.active {
+			if i := slices.Index(m.history, next); i >= 0 {
+				// returning to an earlier window drops everything since
+				m.history = m.history[:i]
+			} else {
+				m.history = append(m.history, m.active)
+			}
+
+			m.active = next
 		}
+	case ViewMsg[*Story], ViewMsg[*Comment], ViewMsg[*PollOpt], KidsMsg, frameMsg:
+		// deliver to the view even while another window is active
+		_, cmd := m.view.Update(msg)
+		return m, cmd
+	case ListMsg[string]:
+		return m, m.updateList(msg.pane, msg)
+	case ListMsg[time.Time]:
+		return m, m.updateList(msg.pane, msg)
+	case ListMsg[[]int]:
+		return m, m.updateList(msg.pane, msg)
+	case ListMsg[*Story]:
+		return m, m.updateList(msg.pane, msg)
+	case UserMsg:
+		_, cmd := m.user.Update(msg)
+		return m, cmd
+	case SearchMsg:
+		_, cmd := m.search.Update(msg)
+		return m, cmd
+	case ArticleMsg:
+		_, cmd := m.reader.Update(msg)
+		return m, cmd
+	case UpdatesMsg:
+		_, list := m.list.Update(msg)
+		_, view := m.view.Update(msg)
+		return m, bbt.Batch(list, view, m.waitUpdates)
+	case ExportMsg:
+		notice := "exported to " + msg.Value
+		if msg.Failed > 0 {
+			notice += fmt.Sprintf(" (%d comments failed to load)", msg.Failed)
+		}
+
+		m.log.Notify(notice)
+		return m, bbt.Tick(errorBanner, func(time.Time) bbt.Msg {
+			return errorExpiredMsg{}
+		})
+	case ErrorMsg:
+		m.log.Add(msg)
+		_, cmd := m.errors.Update(msg)
+		return m, bbt.Batch(cmd, bbt.Tick(errorBanner, func(time.Time) bbt.Msg {
+			return errorExpiredMsg{}
+		}))
 	case bbt.WindowSizeMsg:
+		m.width = msg.Width
+
 		var cmds []bbt.Cmd
-		for _, window := range []Window{m.list, m.view} {
+		for _, window := range []Window{m.list, m.view, m.errors, m.user, m.search, m.reader} {
 			_, cmd := window.Update(msg)
 			cmds = append(cmds, cmd)
 		}
@@ -59,12 +231,186 @@ func (m *Model) Update(msg bbt.Msg) (bbt.Model, bbt.Cmd) {
 	return m, cmd
 }
 
+// updateList delivers msg to the window showing pane, even while another
+// window is active.
+func (m *Model) updateList(pane *PaneList, msg bbt.Msg) bbt.Cmd {
+	var window Window = m.list
+	if w, ok := m.lists[pane]; ok {
+		window = w
+	}
+
+	_, cmd := window.Update(msg)
+	return cmd
+}
+
+// errorExpiredMsg redraws the footer once an error or notice banner
+// expires.
+type errorExpiredMsg struct{}
+
 func (m *Model) View() string {
-	return m.active.View()
+	return m.config.Hyperlinks.Resolve(m.active.View(), m.width)
+}
+
+// ClientFlags are the command line flags shared by every command which
+// talks to the API.
+type ClientFlags struct {
+	api        string
+	userAgent  string
+	timeout    time.Duration
+	workers    int
+	retries    int
+	cacheDir   string
+	noCache    bool
+	clearCache bool
+	offline    bool
+	stream     bool
+	searchAPI  string
+}
+
+func NewClientFlags(fs *flag.FlagSet) *ClientFlags {
+	var f ClientFlags
+	fs.StringVar(&f.api, "api", "https://hacker-news.firebaseio.com/v0", "Hacker News API base URL")
+	fs.StringVar(&f.userAgent, "user-agent", "termhnal", "User-Agent header sent with API requests")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "timeout for each API request")
+	fs.IntVar(&f.workers, "workers", 8, "maximum number of concurrent API requests")
+	fs.IntVar(&f.retries, "retries", DefaultRetryPolicy.Retries, "times to retry requests which fail with transient errors")
+	fs.StringVar(&f.cacheDir, "cache-dir", "", "directory for cached API responses (default $XDG_CACHE_HOME/termhnal)")
+	fs.BoolVar(&f.noCache, "no-cache", false, "bypass the on-disk cache")
+	fs.BoolVar(&f.clearCache, "clear-cache", false, "remove all responses cached from the API before starting")
+	fs.BoolVar(&f.offline, "offline", false, "serve everything from the cache without touching the network")
+	fs.StringVar(&f.searchAPI, "search-api", "https://hn.algolia.com/api/v1", "Algolia Hacker News Search API base URL")
+	fs.BoolVar(&f.stream, "stream", false, "follow story lists and threads live with server-sent events")
+	return &f
+}
+
+// HN builds a client from the parsed flags.
+func (f *ClientFlags) HN() (*HN, error) {
+	if f.offline && (f.noCache || f.clearCache) {
+		return nil, errors.New("-offline requires the cache")
+	}
+
+	baseURL, err := url.Parse(f.api)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -api: %w", err)
+	}
+
+	searchURL, err := url.Parse(f.searchAPI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -search-api: %w", err)
+	}
+
+	opts := []HNOption{
+		WithBaseURL(baseURL),
+		WithSearchURL(searchURL),
+		WithHTTPClient(&http.Client{Timeout: f.timeout}),
+		WithUserAgent(f.userAgent),
+		WithWorkers(f.workers),
+	}
+
+	retry := DefaultRetryPolicy
+	retry.Retries = f.retries
+	opts = append(opts, WithRetry(retry))
+
+	if f.cacheDir == "" {
+		if f.cacheDir, err = DefaultCacheDir(); err != nil {
+			if f.offline {
+				// there would be nothing to serve
+				return nil, fmt.Errorf("-offline requires the cache: %w", err)
+			} else if f.clearCache {
+				return nil, fmt.Errorf("clear cache: %w", err)
+			}
+
+			fmt.Fprintln(os.Stderr, "cache disabled:", err)
+			f.noCache = true
+		}
+	}
+
+	// without a directory, a cache would be relative to the working
+	// directory
+	if f.cacheDir != "" {
+		cache := NewCache(filepath.Join(f.cacheDir, CacheNamespace(baseURL)))
+		if f.clearCache {
+			if err := cache.Clear(); err != nil {
+				return nil, fmt.Errorf("clear cache: %w", err)
+			}
+		}
+
+		if !f.noCache {
+			opts = append(opts, WithCache(cache))
+		}
+	}
+
+	if f.offline {
+		opts = append(opts, WithOffline())
+	}
+
+	if f.stream {
+		opts = append(opts, WithStreaming())
+	}
+
+	return NewHN(opts...), nil
+}
+
+// commands are the non-interactive subcommands, e.g. termhnal sync.
+var commands = map[string]func(args []string) error{
+	"sync":   runSync,
+	"export": runExport,
 }
 
 func main() {
-	if _, err := bbt.NewProgram(NewModel(), bbt.WithAltScreen()).Run(); err != nil {
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); errors.Is(err, flag.ErrHelp) {
+				os.Exit(2)
+			} else if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
+	flags := NewClientFlags(flag.CommandLine)
+
+	var config Config
+	flag.BoolVar(&config.ShowDead, "show-dead", false, "show the text of dead stories and comments")
+	flag.DurationVar(&config.Updates, "updates", 30*time.Second, "how often to poll for live updates, or 0 to disable them")
+	browser := flag.String("browser", "", "command which opens links, with {} replaced by the link (default $BROWSER or the system opener)")
+	hyperlinks := flag.String("hyperlinks", "auto", "make links clickable with OSC 8: auto, always or never")
+	flag.StringVar(&config.ExportDir, "export-dir", "", "directory stories are exported to (default $XDG_DOWNLOAD_DIR or the home directory)")
+	flag.Parse()
+
+	config.Opener = NewOpener(*browser, nil)
+
+	switch *hyperlinks {
+	case "auto":
+		config.Hyperlinks = NewHyperlinks(HyperlinksSupported(os.Getenv))
+	case "always":
+		config.Hyperlinks = NewHyperlinks(true)
+	case "never":
+		config.Hyperlinks = NewHyperlinks(false)
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -hyperlinks %q: must be auto, always or never\n", *hyperlinks)
+		os.Exit(2)
+	}
+
+	hn, err := flags.HN()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defer hn.Close()
+
+	// copies are written to the terminal between frames rather than within
+	// them
+	output := NewTerminal(os.Stdout)
+	config.Clipboard = NewClipboard(output, nil)
+
+	program := bbt.NewProgram(NewModel(hn, &config), bbt.WithAltScreen(), bbt.WithOutput(output))
+	go output.WatchSize(program)
+
+	if _, err := program.Run(); err != nil {
 		panic(err)
 	}
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// withoutCacheDir leaves no way to find the cache directory, and runs the
+// test in an empty working directory, returning it.
+func withoutCacheDir(t *testing.T) string {
+	t.Helper()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("the cache directory is found without $HOME")
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("HOME", "")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	return dir
+}
+
+func parseClientFlags(t *testing.T, args ...string) *ClientFlags {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := NewClientFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return flags
+}
+
+func TestOfflineWithoutCache(t *testing.T) {
+	withoutCacheDir(t)
+
+	if hn, err := parseClientFlags(t, "-offline").HN(); err == nil {
+		t.Errorf("HN() = %v, want an error rather than going online", hn)
+	}
+}
+
+func TestOfflineWithCache(t *testing.T) {
+	hn, err := parseClientFlags(t, "-offline", "-cache-dir", t.TempDir()).HN()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hn.Close()
+
+	if !hn.Offline() {
+		t.Error("Offline() = false, want true")
+	}
+}
+
+func TestClearCacheWithoutCacheDir(t *testing.T) {
+	dir := withoutCacheDir(t)
+
+	// what the cache would be named relative to the working directory
+	namespace := filepath.Join(dir, "hacker-news.firebaseio.com_v0")
+	if err := os.Mkdir(namespace, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseClientFlags(t, "-clear-cache").HN(); err == nil {
+		t.Error("HN() = nil, want an error")
+	}
+
+	if _, err := os.Stat(namespace); err != nil {
+		t.Errorf("the working directory was cleared: %v", err)
+	}
+}
+
+func TestNoCacheDir(t *testing.T) {
+	dir := withoutCacheDir(t)
+
+	hn, err := parseClientFlags(t).HN()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hn.Close()
+
+	if hn.cache != nil {
+		t.Error("cache is set, want it disabled")
+	}
+
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) > 0 {
+		t.Errorf("working directory has %v, want it left alone", entries)
+	}
+}
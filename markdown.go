@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// markdownEscaper escapes text which Markdown would read as markup.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+)
+
+// markdownURLEscaper escapes the characters which would end a link in
+// angle brackets early.
+var markdownURLEscaper = strings.NewReplacer(
+	" ", "%20",
+	"<", "%3C",
+	">", "%3E",
+)
+
+// fence returns a run of at least n backticks longer than any in code, so
+// code can be wrapped in it.
+func fence(code string, n int) string {
+	var run int
+	for _, r := range code {
+		if r != '`' {
+			run = 0
+			continue
+		}
+
+		if run++; run >= n {
+			n = run + 1
+		}
+	}
+
+	return strings.Repeat("`", n)
+}
+
+// Markdown converts text from HN to Markdown. Quotes keep the leading >
+// which HN uses for them, and so are Markdown quotes already.
+func Markdown(text string) string {
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return text
+	}
+
+	r := newHTMLRenderer(hnURL, false, nil)
+
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(markdownEscaper.Replace(n.Data))
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "p":
+				sb.WriteString("\n\n")
+			case "br":
+				sb.WriteString("  \n")
+				return
+			case "pre":
+				code := strings.TrimRight(strings.TrimPrefix(textContent(n), "\n"), "\n")
+				fence := fence(code, 3)
+				fmt.Fprintf(&sb, "\n\n%s\n%s\n%s\n\n", fence, code, fence)
+				return
+			case "code":
+				code := textContent(n)
+				if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+					// a space on each side is dropped, keeping the
+					// backticks apart from the fence
+					code = " " + code + " "
+				}
+
+				fence := fence(code, 1)
+				fmt.Fprintf(&sb, "%s%s%s", fence, code, fence)
+				return
+			case "i", "em":
+				sb.WriteString("*")
+				defer sb.WriteString("*")
+			case "b", "strong":
+				sb.WriteString("**")
+				defer sb.WriteString("**")
+			case "a":
+				link := r.resolve(attr(n, "href"))
+				if link == "" {
+					break
+				}
+
+				if text := strings.TrimSpace(textContent(n)); text == link || strings.HasSuffix(text, "...") && strings.HasPrefix(link, strings.TrimSuffix(text, "...")) {
+					// HN truncates long links, so use the whole link
+					fmt.Fprintf(&sb, "<%s>", markdownURLEscaper.Replace(link))
+					return
+				}
+
+				sb.WriteString("[")
+				defer fmt.Fprintf(&sb, "](<%s>)", markdownURLEscaper.Replace(link))
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(root)
+
+	// drop the blank lines left by empty paragraphs
+	var paragraphs []string
+	for _, paragraph := range strings.Split(sb.String(), "\n\n") {
+		if paragraph = strings.Trim(paragraph, "\n"); strings.TrimSpace(paragraph) != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// timestamp formats the time of an item for exports.
+func timestamp(t int64) string {
+	return time.Unix(t, 0).UTC().Format("2006-01-02 15:04 MST")
+}
+
+// WriteMarkdown writes story and the comments loaded below it as Markdown,
+// with replies nested in lists. The text of dead items, and the title of a
+// dead story, is left out unless showDead is set.
+func WriteMarkdown(w io.Writer, story *Story, showDead bool) error {
+	bw := bufio.NewWriter(w)
+
+	story.mu.RLock()
+	if story.Dead && !showDead {
+		// hide the title and link of dead stories
+		fmt.Fprintf(bw, "# [%s](%s)\n\n", markdownEscaper.Replace("[dead]"), ItemURL(story.ID))
+	} else {
+		fmt.Fprintf(bw, "# [%s](<%s>)\n\n", markdownEscaper.Replace(story.Item.Title), markdownURLEscaper.Replace(story.Link()))
+	}
+	fmt.Fprintf(bw, "%d points by [%s](%s) at [%s](%s) | %d comments\n",
+		story.Score, markdownEscaper.Replace(story.By), UserURL(story.By), timestamp(story.Time), ItemURL(story.ID), story.Descendants)
+
+	if story.Text != "" && (!story.Dead || showDead) {
+		fmt.Fprintf(bw, "\n%s\n", Markdown(story.Text))
+	}
+
+	comments := slices.Clone(story.Comments)
+	story.mu.RUnlock()
+
+	if len(comments) > 0 {
+		bw.WriteString("\n---\n")
+	}
+
+	var thread func([]*Comment, int)
+	thread = func(comments []*Comment, depth int) {
+		indent := strings.Repeat("  ", depth)
+		for _, c := range comments {
+			c.mu.RLock()
+			var header, text string
+			switch {
+			case c.err != nil:
+				header = c.placeholder()
+			case c.Deleted:
+				header = "[deleted]"
+			case c.Dead && !showDead:
+				header = "[dead]"
+			default:
+				header = fmt.Sprintf("**[%s](%s)** at [%s](%s)", markdownEscaper.Replace(c.By), UserURL(c.By), timestamp(c.Time), ItemURL(c.ID))
+				text = Markdown(c.Text)
+			}
+
+			replies := slices.Clone(c.Comments)
+			c.mu.RUnlock()
+
+			fmt.Fprintf(bw, "\n%s- %s\n", indent, header)
+			if text != "" {
+				// continuation lines are indented to stay in the list item
+				for _, line := range strings.Split(text, "\n") {
+					if line == "" {
+						bw.WriteString("\n")
+					} else {
+						fmt.Fprintf(bw, "%s  %s\n", indent, line)
+					}
+				}
+			}
+
+			thread(replies, depth+1)
+		}
+	}
+
+	thread(comments, 0)
+	return bw.Flush()
+}
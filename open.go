@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	bbt "github.com/charmbracelet/bubbletea"
+)
+
+// webURL is the root of the Hacker News website.
+const webURL = "https://news.ycombinator.com"
+
+// ItemURL is the page for a story or comment on Hacker News.
+func ItemURL(id int) string {
+	return fmt.Sprintf("%s/item?id=%d", webURL, id)
+}
+
+// UserURL is the profile page for a user on Hacker News.
+func UserURL(id string) string {
+	return fmt.Sprintf("%s/user?id=%s", webURL, id)
+}
+
+// Runner starts an external command without waiting for it to finish.
+type Runner func(name string, args ...string) error
+
+// StartCommand is the default Runner. The command's output is discarded
+// so it cannot draw over the terminal.
+func StartCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// reap the command once it exits
+	go cmd.Wait()
+	return nil
+}
+
+// Opener opens links in an external browser.
+type Opener struct {
+	template string
+	run      Runner
+}
+
+// NewOpener opens links with the command line template, where {} or %s
+// is replaced by the link, or the link is appended if there is neither.
+// An empty template uses $BROWSER, or the platform's default opener.
+func NewOpener(template string, run Runner) *Opener {
+	if template == "" {
+		// $BROWSER may be a list of commands to try, separated by colons
+		template, _, _ = strings.Cut(os.Getenv("BROWSER"), string(os.PathListSeparator))
+	}
+
+	if template == "" {
+		switch runtime.GOOS {
+		case "darwin":
+			template = "open"
+		case "windows":
+			template = "rundll32 url.dll,FileProtocolHandler"
+		default:
+			template = "xdg-open"
+		}
+	}
+
+	if run == nil {
+		run = StartCommand
+	}
+
+	return &Opener{template: template, run: run}
+}
+
+// Command returns the command line which opens link.
+func (o *Opener) Command(link string) []string {
+	fields := strings.Fields(o.template)
+
+	var replaced bool
+	for i, field := range fields {
+		for _, placeholder := range []string{"{}", "%s"} {
+			if strings.Contains(field, placeholder) {
+				fields[i] = strings.ReplaceAll(field, placeholder, link)
+				replaced = true
+			}
+		}
+	}
+
+	if !replaced {
+		fields = append(fields, link)
+	}
+
+	return fields
+}
+
+// Open opens link in the browser.
+func (o *Opener) Open(link string) error {
+	if link == "" {
+		return errors.New("nothing to open")
+	}
+
+	command := o.Command(link)
+	if err := o.run(command[0], command[1:]...); err != nil {
+		return fmt.Errorf("open %s: %w", link, err)
+	}
+
+	return nil
+}
+
+// Open opens link with opener, reporting failures as an ErrorMsg.
+func Open(opener *Opener, link string) bbt.Cmd {
+	return func() bbt.Msg {
+		if err := opener.Open(link); err != nil {
+			return ErrorMsg{Err: err, Time: time.Now()}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// recordRunner returns a Runner which records the command lines it is given.
+func recordRunner(err error) (Runner, *[][]string) {
+	var commands [][]string
+	return func(name string, args ...string) error {
+		commands = append(commands, append([]string{name}, args...))
+		return err
+	}, &commands
+}
+
+func TestOpenerCommand(t *testing.T) {
+	const link = "https://example.com/a?b=c"
+	for _, tt := range []struct {
+		template string
+		want     []string
+	}{
+		{"firefox", []string{"firefox", link}},
+		{"firefox --new-tab {}", []string{"firefox", "--new-tab", link}},
+		{"chromium %s --incognito", []string{"chromium", link, "--incognito"}},
+		{"open -u={}", []string{"open", "-u=" + link}},
+	} {
+		run, commands := recordRunner(nil)
+		if err := NewOpener(tt.template, run).Open(link); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(*commands) != 1 || !slices.Equal((*commands)[0], tt.want) {
+			t.Errorf("%q ran %q, want %q", tt.template, *commands, tt.want)
+		}
+	}
+}
+
+func TestOpenerBrowserEnv(t *testing.T) {
+	t.Setenv("BROWSER", "w3m:lynx")
+
+	run, commands := recordRunner(nil)
+	if err := NewOpener("", run).Open("https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"w3m", "https://example.com"}; len(*commands) != 1 || !slices.Equal((*commands)[0], want) {
+		t.Errorf("ran %q, want %q", *commands, want)
+	}
+}
+
+func TestOpenerErrors(t *testing.T) {
+	run, commands := recordRunner(errors.New("not found"))
+	opener := NewOpener("firefox", run)
+
+	if err := opener.Open(""); err == nil || len(*commands) != 0 {
+		t.Errorf("Open(\"\") = %v after running %q, want an error without running anything", err, *commands)
+	}
+
+	if err := opener.Open("https://example.com"); err == nil {
+		t.Error("Open() = nil, want the runner's error")
+	}
+
+	msg := Open(opener, "https://example.com")()
+	if e, ok := msg.(ErrorMsg); !ok || e.Err == nil {
+		t.Errorf("Open() cmd = %#v, want an ErrorMsg", msg)
+	}
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/bubbles/textinput"
 	bbt "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -31,12 +34,117 @@ func Activate(name string) bbt.Cmd {
 	}
 }
 
+// ErrorMsg reports a failed request. Retry, if not nil, repeats it.
+type ErrorMsg struct {
+	Err   error
+	Time  time.Time
+	Retry bbt.Cmd
+}
+
+func Error(err error, retry bbt.Cmd) bbt.Cmd {
+	return func() bbt.Msg {
+		return ErrorMsg{
+			Err:   err,
+			Time:  time.Now(),
+			Retry: retry,
+		}
+	}
+}
+
+func (e ErrorMsg) FilterValue() string {
+	return e.Err.Error()
+}
+
+func (e ErrorMsg) Title() string {
+	return e.Err.Error()
+}
+
+func (e ErrorMsg) Description() string {
+	if e.Retry != nil {
+		return fmt.Sprintf("%s | r to retry", humanize(e.Time))
+	}
+
+	return humanize(e.Time)
+}
+
+// ErrorLog keeps the most recent errors, newest first, and the most
+// recent notice, e.g. where a story was exported to.
+type ErrorLog struct {
+	errors []ErrorMsg
+
+	notice   string
+	noticeAt time.Time
+}
+
+// maxErrors is the number of errors kept by ErrorLog.
+const maxErrors = 100
+
+func (l *ErrorLog) Add(e ErrorMsg) {
+	l.errors = append([]ErrorMsg{e}, l.errors...)
+	if len(l.errors) > maxErrors {
+		l.errors = l.errors[:maxErrors]
+	}
+}
+
+func (l *ErrorLog) Remove(i int) {
+	l.errors = append(l.errors[:i], l.errors[i+1:]...)
+}
+
+// Recent returns the newest error if it happened within d.
+func (l *ErrorLog) Recent(d time.Duration) (ErrorMsg, bool) {
+	if len(l.errors) > 0 && time.Since(l.errors[0].Time) < d {
+		return l.errors[0], true
+	}
+
+	return ErrorMsg{}, false
+}
+
+// Notify sets the notice.
+func (l *ErrorLog) Notify(notice string) {
+	l.notice, l.noticeAt = notice, time.Now()
+}
+
+// Notice returns the notice if it was set within d.
+func (l *ErrorLog) Notice(d time.Duration) (string, bool) {
+	if l.notice != "" && time.Since(l.noticeAt) < d {
+		return l.notice, true
+	}
+
+	return "", false
+}
+
+// UpdatesMsg carries items and profiles which changed recently.
+type UpdatesMsg struct {
+	Value *Updates
+}
+
+// KidsMsg carries the latest kids of a streamed item.
+type KidsMsg struct {
+	ID    int
+	Value []int
+
+	ctx context.Context
+
+	// next waits for the kids to change again.
+	next bbt.Cmd
+}
+
 type ViewType interface {
-	*Story | *Comment
+	*Story | *Comment | *PollOpt
 }
 
 type ViewMsg[T ViewType] struct {
 	Value T
+
+	// ctx is the scope the value was fetched in. Values from a cancelled
+	// scope are stale and dropped.
+	ctx context.Context
+
+	// retry refetches Value if it is a placeholder which failed to load.
+	retry bbt.Cmd
+
+	// refresh marks a newer copy of an item which is already shown.
+	refresh bool
 }
 
 func View[T ViewType](t T) bbt.Cmd {
@@ -49,472 +157,2483 @@ func View[T ViewType](t T) bbt.Cmd {
 
 type PaneView struct {
 	*Story
-	style lipgloss.Style
-	model viewport.Model
+	hn     *HN
+	config *Config
+	style  lipgloss.Style
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// head holds the rendered story above the comments
+	head []string
+
+	// offset is the first line in view and total the number of lines
+	offset, total int
+
+	// blocks caches each comment's rendered lines by ID
+	blocks map[int]*block
 
-	content strings.Builder
+	// pending is set while a frame is scheduled
+	pending bool
+
+	// cursor is the ID of the selected comment, if any
+	cursor int
+
+	// chord is the first key of a two key binding, e.g. o in o u
+	chord string
+
+	// collapsed holds the IDs of comments whose replies are hidden. It is
+	// kept across renders so it applies to comments as they arrive.
+	collapsed map[int]bool
+
+	// order holds the comments laid out from top to bottom, and offsets
+	// the line each one's text starts on
+	order   []*Comment
+	offsets map[int]int
 
 	styleTitle        lipgloss.Style
 	styleDescription  lipgloss.Style
 	styleCommentTitle lipgloss.Style
 	styleOP           lipgloss.Style
+	styleNew          lipgloss.Style
 }
 
-func NewPaneView() *PaneView {
+func NewPaneView(hn *HN, config *Config) *PaneView {
 	return &PaneView{
-		style: lipgloss.NewStyle().Margin(1, 2),
-		model: viewport.New(0, 0),
+		hn:     hn,
+		config: config,
+		ctx:    context.Background(),
+		cancel: func() {},
+		style:  lipgloss.NewStyle().Margin(1, 2),
+
+		blocks:    make(map[int]*block),
+		collapsed: make(map[int]bool),
+		offsets:   make(map[int]int),
+
 		styleTitle: lipgloss.NewStyle().
 			Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"}),
 		styleDescription: lipgloss.NewStyle().
 			Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}),
 		styleCommentTitle: lipgloss.NewStyle().Foreground(lipgloss.Color("#ff6600")),
 		styleOP:           lipgloss.NewStyle().Foreground(lipgloss.Color("#0099ff")).SetString("OP"),
+		styleNew:          lipgloss.NewStyle().Foreground(lipgloss.Color("#00aa00")).Bold(true).SetString("new"),
 	}
 }
 
-func (p *PaneView) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
-	comments := func(parent *Item) []bbt.Cmd {
-		hn := NewHN()
-		var cmds []bbt.Cmd
-		for i := range parent.Kids {
-			i := i
-			cmds = append(cmds, func() bbt.Msg {
-				comment, err := hn.Comment(i, parent.Kids[i])
-				if err != nil {
-					return err
-				}
+// Cancel aborts any comments still being fetched for the current story.
+func (p *PaneView) Cancel() {
+	p.cancel()
+}
 
-				parent.AddComment(comment)
+// frame is how often comments arriving in bulk are drawn.
+const frame = time.Second / 30
 
-				return ViewMsg[*Comment]{
-					Value: comment,
-				}
-			})
-		}
+// frameMsg draws everything which arrived since the last frame.
+type frameMsg struct{}
 
-		return cmds
+// redraw renders on the next frame, so comments arriving in the meantime
+// are drawn together.
+func (p *PaneView) redraw() bbt.Cmd {
+	if p.pending {
+		return nil
 	}
 
-	switch msg := msg.(type) {
-	case ViewMsg[*Story]:
-		p.Story = msg.Value
-		p.Render()
-		return p, bbt.Batch(comments(msg.Value.Item)...)
-	case ViewMsg[*Comment]:
-		p.Render()
-		return p, bbt.Batch(comments(msg.Value.Item)...)
-	case bbt.KeyMsg:
-		switch msg.String() {
-		case "k", "up":
-			if p.model.AtTop() {
-				return p, Activate("header")
+	p.pending = true
+	return bbt.Tick(frame, func(time.Time) bbt.Msg {
+		return frameMsg{}
+	})
+}
+
+// comment fetches the kid id of parent and adds it to parent at rank, or
+// a placeholder if it fails to load. Fresh comments are highlighted as new
+// arrivals.
+func (p *PaneView) comment(ctx context.Context, parent *Item, rank, id int, fresh bool) bbt.Cmd {
+	var cmd bbt.Cmd
+	cmd = func() bbt.Msg {
+		comment, err := p.hn.Comment(ctx, rank, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
 			}
-		case "g", "home":
-			p.model.GotoTop()
-		case "G", "end":
-			p.model.GotoBottom()
-		case "tab":
-			return p, Activate("toggle")
+
+			comment = NewComment(rank)
+			comment.ID = id
+			comment.Parent = parent.ID
+			comment.err = err
+		}
+
+		comment.fresh = fresh
+		parent.AddComment(comment)
+
+		return ViewMsg[*Comment]{
+			Value: comment,
+			ctx:   ctx,
+			retry: cmd,
 		}
-	case bbt.WindowSizeMsg:
-		p.Render()
 	}
 
-	var cmd bbt.Cmd
-	p.model, cmd = p.model.Update(msg)
-	return p, cmd
+	return cmd
 }
 
-func (p *PaneView) View() string {
-	return p.style.Render(p.model.View())
-}
+// refresh refetches an item which changed since it was loaded.
+func (p *PaneView) refresh(ctx context.Context, item *Item) bbt.Cmd {
+	rank, id := item.Rank, item.ID
+	if p.Story != nil && item == p.Story.Item {
+		return func() bbt.Msg {
+			story, err := p.hn.Story(ctx, rank, id)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
 
-func (p *PaneView) Render() {
-	p.content.Reset()
-	if s := p.Story; s != nil {
-		title := strings.TrimPrefix(s.Title(), fmt.Sprintf("%d. ", s.Rank+1))
-		fmt.Fprintln(&p.content, p.styleTitle.Render(title))
+				return ErrorMsg{Err: err, Time: time.Now()}
+			}
 
-		description := strings.TrimSpace(s.Description())
-		fmt.Fprintln(&p.content, p.styleDescription.Render(description))
+			return ViewMsg[*Story]{
+				Value:   story,
+				ctx:     ctx,
+				refresh: true,
+			}
+		}
+	}
 
-		if s.URL != "" {
-			fmt.Fprintln(&p.content, p.styleDescription.Copy().Underline(true).Italic(true).Render(s.URL))
-		} else if s.Text != "" {
-			fmt.Fprintln(&p.content, p.styleDescription.Copy().MarginTop(1).Width(p.style.GetWidth()).Render(HTMLText(s.Text)))
+	return func() bbt.Msg {
+		comment, err := p.hn.Comment(ctx, rank, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return ErrorMsg{Err: err, Time: time.Now()}
 		}
 
-		styleComment := lipgloss.NewStyle().
-			Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"}).
-			Border(lipgloss.NormalBorder(), false).
-			BorderLeft(true).
-			PaddingLeft(1).
-			MarginTop(1)
-
-		h, _ := styleComment.GetFrameSize()
-
-		var view func(lipgloss.Style, []*Comment) string
-		view = func(style lipgloss.Style, comments []*Comment) string {
-			var lines []string
-			for _, comment := range comments {
-				if comment.By != "" {
-					render := func() string {
-						comment.mu.RLock()
-						defer comment.mu.RUnlock()
-
-						var sb strings.Builder
-						by := p.styleCommentTitle.Render(comment.By)
-						if comment.By == s.By {
-							by = fmt.Sprintf("%s %s", by, p.styleOP.String())
-						}
-
-						fmt.Fprintln(&sb, by, p.styleCommentTitle.Copy().Faint(true).Render(humanize(time.Unix(comment.Time, 0))))
-
-						sb.WriteString(HTMLText(comment.Text))
-
-						if len(comment.Comments) > 0 {
-							fmt.Fprintln(&sb)
-							fmt.Fprint(&sb, view(style.Copy().Width(style.GetWidth()-h), comment.Comments))
-						}
-
-						return sb.String()
-					}
+		return ViewMsg[*Comment]{
+			Value:   comment,
+			ctx:     ctx,
+			refresh: true,
+		}
+	}
+}
 
-					lines = append(lines, style.Render(render()))
-				}
+// kids streams the kids of item id so new comments arrive as they are posted.
+func (p *PaneView) kids(ctx context.Context, id int) bbt.Cmd {
+	var follow func(<-chan []int) bbt.Cmd
+	follow = func(ch <-chan []int) bbt.Cmd {
+		return func() bbt.Msg {
+			kids, ok := <-ch
+			if !ok {
+				return nil
 			}
 
-			return strings.Join(lines, "\n")
+			return KidsMsg{
+				ID:    id,
+				Value: kids,
+				ctx:   ctx,
+				next:  follow(ch),
+			}
 		}
+	}
 
-		s.mu.RLock()
-		defer s.mu.RUnlock()
+	var cmd bbt.Cmd
+	cmd = func() bbt.Msg {
+		ch, err := p.hn.StreamKids(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
 
-		fmt.Fprintln(&p.content, view(styleComment.Copy().Width(p.style.GetWidth()-h), s.Comments))
+			return ErrorMsg{
+				Err:   fmt.Errorf("stream %d: %w", id, err),
+				Time:  time.Now(),
+				Retry: cmd,
+			}
+		}
+
+		return follow(ch)()
 	}
 
-	p.model.SetContent(p.content.String())
+	return cmd
 }
 
-func (p *PaneView) Size() (width, height int) {
-	h, v := p.style.GetFrameSize()
-	return p.style.GetWidth() + h, p.style.GetHeight() + v
-}
+// option fetches the i-th option of the poll story and adds it to story,
+// or a placeholder if it fails to load.
+func (p *PaneView) option(ctx context.Context, story *Story, i int) bbt.Cmd {
+	var cmd bbt.Cmd
+	cmd = func() bbt.Msg {
+		option, err := p.hn.PollOpt(ctx, i, story.Parts[i])
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
 
-func (p *PaneView) SetSize(width, height int) {
-	h, v := p.style.GetFrameSize()
-	p.style = p.style.Width(width - h).Height(height - v)
-	p.model.Width, p.model.Height = width-h, height-v
-}
+			option = NewPollOpt(i)
+			option.ID = story.Parts[i]
+			option.err = err
+		}
 
-func (p *PaneView) Activate() Pane {
-	p.model.GotoTop()
-	return p
-}
+		story.AddOption(option)
 
-func (p *PaneView) Deactivate() {
-}
+		return ViewMsg[*PollOpt]{
+			Value: option,
+			ctx:   ctx,
+			retry: cmd,
+		}
+	}
 
-type ListType interface {
-	string | *Story
+	return cmd
 }
 
-type ListMsg[T ListType] struct {
-	Value T
-}
+func (p *PaneView) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
+	comments := func(parent *Item) []bbt.Cmd {
+		ctx := p.ctx
+		if parent == p.Story.Item {
+			// top level comments are visible first
+			ctx = WithPriority(ctx, PriorityHigh)
+		}
 
-func List[T ListType](t T) bbt.Cmd {
-	return func() bbt.Msg {
-		return ListMsg[T]{
-			Value: t,
+		var cmds []bbt.Cmd
+		for i, id := range parent.Kids {
+			cmds = append(cmds, p.comment(ctx, parent, i, id, false))
 		}
-	}
-}
 
-type PaneList struct {
-	model list.Model
-	style lipgloss.Style
-}
+		return cmds
+	}
 
-func NewPaneList() *PaneList {
-	color := lipgloss.Color("#ff6600")
-	delegate := list.NewDefaultDelegate()
-	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(color).BorderLeftForeground(color)
-	delegate.Styles.SelectedDesc = delegate.Styles.SelectedTitle.Copy().Faint(true)
+	// added fetches kids which appeared when parent was refreshed
+	added := func(parent *Item, ranks []int) []bbt.Cmd {
+		var cmds []bbt.Cmd
+		for _, rank := range ranks {
+			cmds = append(cmds, p.comment(p.ctx, parent, rank, parent.Kids[rank], true))
+		}
 
-	model := list.New([]list.Item{}, delegate, 0, 0)
-	model.SetShowHelp(false)
-	model.SetShowStatusBar(false)
-	model.SetShowTitle(false)
-	model.SetShowPagination(false)
-	return &PaneList{
-		model: model,
-		style: lipgloss.NewStyle().Margin(1, 2),
+		return cmds
 	}
-}
 
-func (p *PaneList) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
-	hn := NewHN()
 	switch msg := msg.(type) {
-	case ListMsg[string]:
-		var fn func() ([]int, error)
-		switch strings.ToLower(msg.Value) {
-		case "top":
-			fn = hn.Top
-		case "new":
-			fn = hn.New
-		case "best":
-			fn = hn.Best
-		case "ask":
-			fn = hn.Ask
-		case "show":
-			fn = hn.Show
-		case "job":
-			fn = hn.Job
-		case "clear":
-			p.model.ResetSelected()
-			return p, p.model.SetItems([]list.Item{})
-		default:
+	case UpdatesMsg:
+		if p.Story == nil {
 			return p, nil
 		}
 
-		ids, err := fn()
-		if err != nil {
-			return p, nil
+		changed := make(map[int]bool, len(msg.Value.Items))
+		for _, id := range msg.Value.Items {
+			changed[id] = true
 		}
 
 		var cmds []bbt.Cmd
-		for i := range ids {
-			i := i
-			cmds = append(cmds, func() bbt.Msg {
-				story, err := hn.Story(i, ids[i])
-				if err != nil {
-					return err
-				}
-
-				return ListMsg[*Story]{
-					Value: story,
-				}
-			})
+		if changed[p.Story.ID] {
+			cmds = append(cmds, p.refresh(p.ctx, p.Story.Item))
 		}
 
-		return p, bbt.Batch(cmds...)
-	case ListMsg[*Story]:
-		items := append(p.model.Items(), msg.Value)
-		slices.SortFunc(items, func(i, j list.Item) int {
-			return cmp.Compare(i.(*Story).Rank, j.(*Story).Rank)
+		p.Story.walk(func(c *Comment) {
+			if changed[c.ID] && c.err == nil {
+				cmds = append(cmds, p.refresh(p.ctx, c.Item))
+			}
 		})
 
-		return p, p.model.SetItems(items)
-	case bbt.KeyMsg:
-		switch msg.String() {
-		case "enter":
-			story := p.model.SelectedItem().(*Story)
-			return p, bbt.Sequence(
-				Activate("view"),
-				View(story),
-			)
-		case "k", "up":
-			if p.model.Index() == 0 {
-				return p, Activate("header")
+		return p, bbt.Batch(cmds...)
+	case ViewMsg[*Story]:
+		if msg.refresh {
+			if (msg.ctx != nil && msg.ctx.Err() != nil) || p.Story == nil || p.Story.ID != msg.Value.ID {
+				return p, nil
 			}
-		case "tab":
-			return p, Activate("toggle")
+
+			ranks := p.Story.Merge(msg.Value)
+			p.Render()
+			return p, bbt.Batch(added(p.Story.Item, ranks)...)
 		}
-	}
 
-	var cmd bbt.Cmd
-	p.model, cmd = p.model.Update(msg)
-	return p, cmd
-}
+		p.Cancel()
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+		p.Story = msg.Value
+		p.cursor = 0
+		p.collapsed = make(map[int]bool)
+		p.blocks = make(map[int]*block)
+		p.Render()
 
-func (p *PaneList) View() string {
-	return p.style.Render(p.model.View())
-}
+		cmds := comments(msg.Value.Item)
+		for i := range msg.Value.Parts {
+			cmds = append(cmds, p.option(WithPriority(p.ctx, PriorityHigh), msg.Value, i))
+		}
 
-func (p *PaneList) Size() (width, height int) {
-	h, v := p.style.GetFrameSize()
-	return p.model.Width() + h, p.model.Height() + v
-}
+		if p.hn.Streaming() {
+			cmds = append(cmds, p.kids(p.ctx, msg.Value.ID))
+		}
 
-func (p *PaneList) SetSize(width, height int) {
-	h, v := p.style.GetFrameSize()
-	p.model.SetSize(width-h, height-v)
-}
+		return p, bbt.Batch(cmds...)
+	case KidsMsg:
+		if msg.ctx.Err() != nil || p.Story == nil || p.Story.ID != msg.ID {
+			return p, nil
+		}
 
-func (p *PaneList) Activate() Pane {
-	return p
-}
+		ranks := p.Story.setKids(msg.Value)
+		return p, bbt.Batch(append(added(p.Story.Item, ranks), msg.next, p.redraw())...)
+	case frameMsg:
+		p.pending = false
+		p.Render()
+		return p, nil
+	case ViewMsg[*PollOpt]:
+		if msg.ctx != nil && msg.ctx.Err() != nil {
+			return p, nil
+		}
 
-func (p *PaneList) Deactivate() {
-}
+		if err := msg.Value.err; err != nil && !errors.Is(err, ErrUnavailable) && !errors.Is(err, ErrNotFound) {
+			return p, bbt.Batch(p.redraw(), Error(err, msg.retry))
+		}
 
-type HeaderMsg int
+		return p, p.redraw()
+	case ViewMsg[*Comment]:
+		if msg.ctx != nil && msg.ctx.Err() != nil {
+			return p, nil
+		}
 
-func Header(n int) bbt.Cmd {
-	return func() bbt.Msg {
+		if msg.refresh {
+			comment := p.Story.find(msg.Value.ID)
+			if comment == nil {
+				return p, nil
+			}
+
+			ranks := comment.Merge(msg.Value)
+			if b, ok := p.blocks[comment.ID]; ok {
+				// keep drawing the old lines until the next frame
+				b.stale = true
+			}
+			return p, bbt.Batch(append(added(comment.Item, ranks), p.redraw())...)
+		}
+
+		if err := msg.Value.err; err != nil {
+			if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrNotFound) {
+				return p, p.redraw()
+			}
+
+			return p, bbt.Batch(p.redraw(), Error(err, msg.retry))
+		}
+
+		return p, bbt.Batch(append(comments(msg.Value.Item), p.redraw())...)
+	case bbt.KeyMsg:
+		if p.chord != "" {
+			chord := p.chord + " " + msg.String()
+			p.chord = ""
+			return p, p.chordCmd(chord)
+		}
+
+		switch msg.String() {
+		case "o", "y", "x":
+			if p.Story != nil {
+				p.chord = msg.String()
+			}
+
+			return p, nil
+		case "k", "up":
+			if p.offset == 0 {
+				return p, Activate("header")
+			}
+
+			p.scroll(p.offset - 1)
+		case "j", "down":
+			p.scroll(p.offset + 1)
+		case "h", "left", "pgup", "b":
+			p.scroll(p.offset - p.style.GetHeight())
+		case "l", "right", "pgdown", "f":
+			p.scroll(p.offset + p.style.GetHeight())
+		case "g", "home":
+			p.scroll(0)
+		case "G", "end":
+			p.scroll(p.total)
+		case "J":
+			p.move(1)
+			return p, nil
+		case "K":
+			p.move(-1)
+			return p, nil
+		case "]":
+			p.sibling(1)
+			return p, nil
+		case "[":
+			p.sibling(-1)
+			return p, nil
+		case "p":
+			p.parent()
+			return p, nil
+		case "P":
+			p.top(0)
+			return p, nil
+		case "}":
+			p.top(1)
+			return p, nil
+		case "{":
+			p.top(-1)
+			return p, nil
+		case "enter", " ":
+			if p.cursor != 0 {
+				p.collapsed[p.cursor] = !p.collapsed[p.cursor]
+				p.Render()
+				p.scrollTo(p.cursor)
+			}
+
+			return p, nil
+		case "C":
+			if p.Story != nil {
+				p.Story.mu.RLock()
+				for _, id := range p.Story.Kids {
+					p.collapsed[id] = true
+				}
+				p.Story.mu.RUnlock()
+
+				if comment := p.Story.find(p.cursor); comment != nil && comment.Parent != p.Story.ID {
+					// the selection is now hidden, so select its thread
+					p.cursor = p.root(comment).ID
+				}
+
+				p.Render()
+				p.scrollTo(p.cursor)
+			}
+
+			return p, nil
+		case "E":
+			p.collapsed = make(map[int]bool)
+			p.Render()
+			p.scrollTo(p.cursor)
+			return p, nil
+		case "u":
+			if p.Story == nil {
+				break
+			}
+
+			by := p.By
+			if comment := p.Story.find(p.cursor); comment != nil {
+				comment.mu.RLock()
+				by = comment.By
+				if comment.err != nil || comment.Deleted || comment.Dead && !p.config.ShowDead {
+					// the author is hidden along with the text
+					by = ""
+				}
+				comment.mu.RUnlock()
+			}
+
+			if by != "" {
+				return p, bbt.Sequence(
+					Activate("user"),
+					ShowUser(by),
+				)
+			}
+		case "R":
+			if p.Story != nil && p.URL != "" {
+				return p, bbt.Sequence(
+					Activate("reader"),
+					ShowArticle(p.Story),
+				)
+			}
+		case "tab":
+			return p, Activate("toggle")
+		}
+	case bbt.WindowSizeMsg:
+		p.Render()
+	}
+
+	return p, nil
+}
+
+// View lays out only the lines in view, from the story and the cached
+// blocks of the comments overlapping it.
+func (p *PaneView) View() string {
+	height := p.style.GetHeight()
+	lines := make([]string, 0, height)
+	for i := p.offset; i < len(p.head) && len(lines) < height; i++ {
+		lines = append(lines, p.head[i])
+	}
+
+	// find the first comment which ends below the top of the view
+	i, _ := slices.BinarySearchFunc(p.order, p.offset, func(c *Comment, offset int) int {
+		return cmp.Compare(p.offsets[c.ID]-1+len(p.blocks[c.ID].Lines()), offset+1)
+	})
+
+	for ; i < len(p.order) && len(lines) < height; i++ {
+		c := p.order[i]
+		start := p.offsets[c.ID] - 1
+		for j, line := range p.blocks[c.ID].Lines() {
+			if start+j >= p.offset && len(lines) < height {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	return p.style.Render(strings.Join(lines, "\n"))
+}
+
+// block is a comment's rendered lines, including the blank line above it.
+// It is reused until the comment, the width or how it is shown changes.
+type block struct {
+	lines []string
+
+	comment  *Comment
+	width    int
+	selected bool
+
+	// hidden is the number of replies hidden by collapsing it, or -1
+	hidden int
+
+	// stale is set when the comment changed and must be rendered again
+	stale bool
+}
+
+// Lines returns the block's lines, or none for a missing block.
+func (b *block) Lines() []string {
+	if b == nil {
+		return nil
+	}
+
+	return b.lines
+}
+
+// Render renders the story and lays out its comments, rendering only those
+// comments whose blocks are missing or out of date.
+func (p *PaneView) Render() {
+	p.head = p.head[:0]
+	p.order = p.order[:0]
+	p.offsets = make(map[int]int)
+	p.total = 0
+
+	if s := p.Story; s != nil {
+		var sb strings.Builder
+		title := strings.TrimPrefix(s.Title(), fmt.Sprintf("%d. ", s.Rank+1))
+		if hidden(s, p.config) {
+			title = "[dead]"
+		}
+
+		fmt.Fprintln(&sb, p.styleTitle.Render(title))
+
+		description := p.styleDescription.Render(strings.TrimSpace(s.Description()))
+		if s.loaded && s.err == nil && !s.Deleted {
+			links := p.config.Hyperlinks
+			when := humanize(time.Unix(s.Time, 0))
+			description = strings.Replace(description, "by "+s.By, "by "+links.Link(UserURL(s.By), s.By), 1)
+			description = strings.Replace(description, when, links.Link(ItemURL(s.ID), when), 1)
+		}
+
+		fmt.Fprint(&sb, description)
+
+		if hidden(s, p.config) {
+			// hide the link and text of dead stories
+		} else if s.URL != "" {
+			fmt.Fprint(&sb, "\n", p.config.Hyperlinks.Link(s.URL, p.styleDescription.Copy().Underline(true).Italic(true).Render(s.URL)))
+		} else if s.Text != "" {
+			fmt.Fprint(&sb, "\n\n", RenderHTML(s.Text, p.style.GetWidth(), p.styleDescription, p.config.Hyperlinks))
+		}
+
+		if len(s.Parts) > 0 {
+			fmt.Fprint(&sb, "\n", p.poll(s))
+		}
+
+		p.head = strings.Split(sb.String(), "\n")
+		p.total = len(p.head)
+
+		s.mu.RLock()
+		comments := slices.Clone(s.Comments)
+		s.mu.RUnlock()
+
+		p.thread(comments, 0)
+	}
+
+	p.scroll(p.offset)
+}
+
+// thread lays out comments at depth, followed by their replies unless they
+// are collapsed.
+func (p *PaneView) thread(comments []*Comment, depth int) {
+	for _, comment := range comments {
+		b := p.block(comment, depth)
+		p.order = append(p.order, comment)
+		p.offsets[comment.ID] = p.total + 1
+		p.total += len(b.lines)
+
+		comment.mu.RLock()
+		replies := slices.Clone(comment.Comments)
+		comment.mu.RUnlock()
+
+		if !p.collapsed[comment.ID] {
+			p.thread(replies, depth+1)
+		}
+	}
+}
+
+// block returns the cached block for comment, rendering it again if it is
+// out of date. Each comment is indented by a border for every ancestor,
+// and the selected comment's own border is highlighted.
+func (p *PaneView) block(comment *Comment, depth int) *block {
+	style := lipgloss.NewStyle().
+		Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"}).
+		Border(lipgloss.NormalBorder(), false).
+		BorderLeft(true).
+		PaddingLeft(1)
+
+	h, _ := style.GetFrameSize()
+	width := p.style.GetWidth() - h*(depth+1)
+
+	hidden := -1
+	if p.collapsed[comment.ID] {
+		comment.mu.RLock()
+		hidden = replies(comment.Item)
+		comment.mu.RUnlock()
+	}
+
+	selected := comment.ID == p.cursor
+	if b, ok := p.blocks[comment.ID]; ok && !b.stale && b.comment == comment && b.width == width && b.selected == selected && b.hidden == hidden {
+		return b
+	}
+
+	style = style.Width(width)
+	if selected {
+		style = style.BorderLeftForeground(lipgloss.Color("#ff6600"))
+	}
+
+	// a blank line separates comments, inside the borders of ancestors
+	indent := strings.Repeat(lipgloss.NormalBorder().Left+" ", depth)
+	lines := []string{strings.TrimRight(indent, " ")}
+	text := p.text(comment, hidden, width-style.GetHorizontalPadding())
+	for _, line := range strings.Split(style.Render(text), "\n") {
+		lines = append(lines, indent+line)
+	}
+
+	b := block{
+		lines:    lines,
+		comment:  comment,
+		width:    width,
+		selected: selected,
+		hidden:   hidden,
+	}
+
+	p.blocks[comment.ID] = &b
+	return &b
+}
+
+// text renders a comment without its replies wrapped at width, noting how
+// many replies are hidden if it is collapsed.
+func (p *PaneView) text(comment *Comment, hidden, width int) string {
+	if comment.err != nil {
+		return p.styleDescription.Render(comment.placeholder())
+	}
+
+	comment.mu.RLock()
+	defer comment.mu.RUnlock()
+
+	var sb strings.Builder
+	switch {
+	case comment.Deleted:
+		sb.WriteString(p.styleDescription.Render("[deleted]"))
+	case comment.Dead && !p.config.ShowDead:
+		sb.WriteString(p.styleDescription.Render("[dead]"))
+	default:
+		by := p.config.Hyperlinks.Link(UserURL(comment.By), p.styleCommentTitle.Render(comment.By))
+		if comment.By == p.Story.By {
+			by = fmt.Sprintf("%s %s", by, p.styleOP.String())
+		}
+
+		when := p.config.Hyperlinks.Link(ItemURL(comment.ID), p.styleCommentTitle.Copy().Faint(true).Render(humanize(time.Unix(comment.Time, 0))))
+		if comment.Dead {
+			when = fmt.Sprintf("%s %s", when, p.styleDescription.Render("[dead]"))
+		}
+
+		if comment.fresh {
+			when = fmt.Sprintf("%s %s", when, p.styleNew.String())
+		}
+
+		fmt.Fprintln(&sb, by, when)
+		sb.WriteString(RenderHTML(comment.Text, width, p.styleTitle, p.config.Hyperlinks))
+	}
+
+	switch hidden {
+	case -1, 0:
+	case 1:
+		fmt.Fprintf(&sb, "\n%s", p.styleDescription.Render("[+] 1 reply hidden"))
+	default:
+		fmt.Fprintf(&sb, "\n%s", p.styleDescription.Render(fmt.Sprintf("[+] %d replies hidden", hidden)))
+	}
+
+	return sb.String()
+}
+
+// replies counts the replies below item, including those not loaded yet.
+func replies(item *Item) int {
+	n := len(item.Kids)
+	for _, c := range item.Comments {
+		c.mu.RLock()
+		n += replies(c.Item)
+		c.mu.RUnlock()
+	}
+
+	return n
+}
+
+// scroll moves the view to start at line offset, keeping it within the
+// content.
+func (p *PaneView) scroll(offset int) {
+	if bottom := p.total - p.style.GetHeight(); offset > bottom {
+		offset = bottom
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	p.offset = offset
+}
+
+// ScrollPercent is how far through the content the view is.
+func (p *PaneView) ScrollPercent() float64 {
+	bottom := p.total - p.style.GetHeight()
+	if bottom <= 0 {
+		return 1
+	}
+
+	return float64(p.offset) / float64(bottom)
+}
+
+// chordCmd runs the two key binding chord.
+func (p *PaneView) chordCmd(chord string) bbt.Cmd {
+	switch chord {
+	case "o u":
+		return Open(p.config.Opener, p.Story.Link())
+	case "o d":
+		return Open(p.config.Opener, ItemURL(p.Story.ID))
+	case "o p":
+		if comment := p.Story.find(p.cursor); comment != nil {
+			return Open(p.config.Opener, ItemURL(comment.ID))
+		}
+	case "y u":
+		return Yank(p.config.Clipboard, p.Story.Link())
+	case "y d":
+		return Yank(p.config.Clipboard, ItemURL(p.Story.ID))
+	case "y t":
+		if comment := p.Story.find(p.cursor); comment != nil {
+			comment.mu.RLock()
+			text := HTMLText(comment.Text)
+			if comment.err != nil || comment.Deleted || comment.Dead && !p.config.ShowDead {
+				// there is no text to copy, or it is hidden
+				text = ""
+			}
+			comment.mu.RUnlock()
+			return Yank(p.config.Clipboard, text)
+		}
+	case "y p":
+		if comment := p.Story.find(p.cursor); comment != nil {
+			return Yank(p.config.Clipboard, ItemURL(comment.ID))
+		}
+	case "y m":
+		var sb strings.Builder
+		if err := WriteMarkdown(&sb, p.Story, p.config.ShowDead); err != nil {
+			return Error(err, nil)
+		}
+
+		return Yank(p.config.Clipboard, sb.String())
+	case "x m":
+		return ExportStory(p.hn, p.Story.ID, p.config.ExportDir, "md", p.config.ShowDead)
+	case "x j":
+		return ExportStory(p.hn, p.Story.ID, p.config.ExportDir, "json", p.config.ShowDead)
+	case "x h":
+		return ExportStory(p.hn, p.Story.ID, p.config.ExportDir, "html", p.config.ShowDead)
+	}
+
+	return nil
+}
+
+// selected returns the selected comment or, without a selection, the
+// first comment in view.
+func (p *PaneView) selected() *Comment {
+	if i := slices.IndexFunc(p.order, func(c *Comment) bool { return c.ID == p.cursor }); i >= 0 {
+		return p.order[i]
+	}
+
+	for _, c := range p.order {
+		if p.offsets[c.ID] >= p.offset {
+			return c
+		}
+	}
+
+	if len(p.order) > 0 {
+		return p.order[len(p.order)-1]
+	}
+
+	return nil
+}
+
+// selectComment moves the cursor to comment and scrolls it into view.
+func (p *PaneView) selectComment(comment *Comment) {
+	if comment == nil {
+		return
+	}
+
+	p.cursor = comment.ID
+	p.Render()
+	p.scrollTo(p.cursor)
+}
+
+// move selects the n-th comment after the selected one, or before it if n
+// is negative. Without a selection it starts from the top of the view.
+func (p *PaneView) move(n int) {
+	current := p.selected()
+	if current == nil {
+		return
+	}
+
+	if current.ID != p.cursor && n > 0 {
+		// the comment in view counts as the first step
+		n--
+	}
+
+	i := slices.Index(p.order, current) + n
+	if i < 0 {
+		i = 0
+	} else if i >= len(p.order) {
+		i = len(p.order) - 1
+	}
+
+	p.selectComment(p.order[i])
+}
+
+// sibling selects the n-th reply to the same parent after the selected
+// comment, or before it if n is negative, or stays put if there is none.
+func (p *PaneView) sibling(n int) {
+	current := p.selected()
+	if current == nil {
+		return
+	}
+
+	parent := p.Story.Item
+	if current.Parent != p.Story.ID {
+		if c := p.Story.find(current.Parent); c != nil {
+			parent = c.Item
+		}
+	}
+
+	parent.mu.RLock()
+	siblings := slices.Clone(parent.Comments)
+	parent.mu.RUnlock()
+
+	if i := slices.Index(siblings, current) + n; i >= 0 && i < len(siblings) {
+		current = siblings[i]
+	}
+
+	p.selectComment(current)
+}
+
+// parent selects the comment the selected comment replies to.
+func (p *PaneView) parent() {
+	current := p.selected()
+	if current != nil && current.Parent != p.Story.ID {
+		if parent := p.Story.find(current.Parent); parent != nil {
+			current = parent
+		}
+	}
+
+	p.selectComment(current)
+}
+
+// top selects the n-th top level comment after the selected comment's
+// thread, or before it if n is negative, like the next link on HN.
+func (p *PaneView) top(n int) {
+	current := p.selected()
+	if current == nil {
+		return
+	}
+
+	current = p.root(current)
+
+	p.Story.mu.RLock()
+	roots := slices.Clone(p.Story.Comments)
+	p.Story.mu.RUnlock()
+
+	if i := slices.Index(roots, current) + n; i >= 0 && i < len(roots) {
+		current = roots[i]
+	}
+
+	p.selectComment(current)
+}
+
+// scrollTo scrolls the comment id into view if it is not already, showing
+// as much of it as fits.
+func (p *PaneView) scrollTo(id int) {
+	start, ok := p.offsets[id]
+	if !ok {
+		return
+	}
+
+	// a comment ends at the blank line before the next one
+	end := p.total
+	if i := slices.IndexFunc(p.order, func(c *Comment) bool { return c.ID == id }); i+1 < len(p.order) {
+		end = p.offsets[p.order[i+1].ID] - 1
+	}
+
+	if height := p.style.GetHeight(); start < p.offset {
+		p.scroll(start - 1)
+	} else if end > p.offset+height {
+		offset := end - height
+		if offset > start-1 {
+			offset = start - 1
+		}
+
+		p.scroll(offset)
+	}
+}
+
+// root returns the top level comment of the thread containing comment.
+func (p *PaneView) root(comment *Comment) *Comment {
+	for comment.Parent != p.Story.ID {
+		parent := p.Story.find(comment.Parent)
+		if parent == nil {
+			break
+		}
+
+		comment = parent
+	}
+
+	return comment
+}
+
+// poll renders the options of a poll as horizontal bars scaled to the most
+// popular option.
+func (p *PaneView) poll(s *Story) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	most := 1
+	for _, option := range s.Options {
+		if option.Score > most {
+			most = option.Score
+		}
+	}
+
+	width := p.style.GetWidth()
+	styleBar := lipgloss.NewStyle().Foreground(lipgloss.Color("#ff6600"))
+
+	var lines []string
+	for _, option := range s.Options {
+		if option.err != nil {
+			lines = append(lines, p.styleDescription.Render(option.placeholder()))
+			continue
+		}
+
+		score := fmt.Sprintf(" %d points", option.Score)
+		n := (width - lipgloss.Width(score)) * option.Score / most
+		if n < 0 {
+			n = 0
+		}
+
+		lines = append(lines,
+			RenderHTML(option.Text, width, p.styleTitle, p.config.Hyperlinks),
+			styleBar.Render(strings.Repeat("█", n))+p.styleDescription.Render(score),
+		)
+	}
+
+	return lipgloss.NewStyle().MarginTop(1).Render(strings.Join(lines, "\n"))
+}
+
+func (p *PaneView) Size() (width, height int) {
+	h, v := p.style.GetFrameSize()
+	return p.style.GetWidth() + h, p.style.GetHeight() + v
+}
+
+func (p *PaneView) SetSize(width, height int) {
+	h, v := p.style.GetFrameSize()
+	p.style = p.style.Width(width - h).Height(height - v)
+}
+
+func (p *PaneView) Activate() Pane {
+	p.offset = 0
+	return p
+}
+
+func (p *PaneView) Deactivate() {
+}
+
+type ListType interface {
+	string | time.Time | []int | *Story
+}
+
+type ListMsg[T ListType] struct {
+	Value T
+
+	// ctx is the scope the value was fetched in. Values from a cancelled
+	// scope are stale and dropped.
+	ctx context.Context
+
+	// retry refetches Value if it is a placeholder which failed to load.
+	retry bbt.Cmd
+
+	// pane is the list which requested Value. Messages without one are
+	// for the front page list.
+	pane *PaneList
+
+	// next waits for the next value of a streamed list.
+	next bbt.Cmd
+}
+
+func List[T ListType](t T) bbt.Cmd {
+	return func() bbt.Msg {
+		return ListMsg[T]{
+			Value: t,
+		}
+	}
+}
+
+// prefetch is the number of pages either side of the current page to load
+// ahead of time.
+const prefetch = 1
+
+type PaneList struct {
+	hn     *HN
+	config *Config
+	model  list.Model
+	style  lipgloss.Style
+
+	// chord is the first key of a two key binding, e.g. o in o u
+	chord string
+
+	// requested tracks which stories have been fetched or are being fetched
+	requested map[int]bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// storyDelegate renders stories in a list, hiding the titles of dead
+// stories unless config.ShowDead is set.
+type storyDelegate struct {
+	list.DefaultDelegate
+	config *Config
+}
+
+func (d storyDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if s, ok := item.(*Story); ok && hidden(s, d.config) {
+		item = deadStory{s}
+	}
+
+	d.DefaultDelegate.Render(w, m, index, item)
+}
+
+// deadStory is a dead story whose title is hidden.
+type deadStory struct {
+	*Story
+}
+
+func (s deadStory) Title() string {
+	return fmt.Sprintf("%d. [dead]", s.Rank+1)
+}
+
+// hidden reports whether s is a dead story whose title and text are hidden.
+func hidden(s *Story, config *Config) bool {
+	return s.loaded && s.err == nil && !s.Deleted && s.Dead && !config.ShowDead
+}
+
+func NewPaneList(hn *HN, config *Config) *PaneList {
+	color := lipgloss.Color("#ff6600")
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(color).BorderLeftForeground(color)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedTitle.Copy().Faint(true)
+
+	model := list.New([]list.Item{}, storyDelegate{delegate, config}, 0, 0)
+	model.SetShowHelp(false)
+	model.SetShowStatusBar(false)
+	model.SetShowTitle(false)
+	model.SetShowPagination(false)
+	return &PaneList{
+		hn:        hn,
+		config:    config,
+		model:     model,
+		style:     lipgloss.NewStyle().Margin(1, 2),
+		requested: make(map[int]bool),
+		ctx:       context.Background(),
+		cancel:    func() {},
+	}
+}
+
+// Cancel aborts the list load in progress, if any.
+func (p *PaneList) Cancel() {
+	p.cancel()
+}
+
+func (p *PaneList) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
+	hn := p.hn
+	switch msg := msg.(type) {
+	case ListMsg[string]:
+		var fn func(context.Context) ([]int, error)
+		switch strings.ToLower(msg.Value) {
+		case "top":
+			fn = hn.Top
+		case "new":
+			fn = hn.New
+		case "best":
+			fn = hn.Best
+		case "ask":
+			fn = hn.Ask
+		case "show":
+			fn = hn.Show
+		case "job":
+			fn = hn.Job
+		case "clear":
+			p.Cancel()
+			p.requested = make(map[int]bool)
+			p.model.ResetSelected()
+			return p, p.model.SetItems([]list.Item{})
+		default:
+			return p, nil
+		}
+
+		p.Cancel()
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+		p.requested = make(map[int]bool)
+
+		ctx := p.ctx
+		if hn.Streaming() {
+			return p, p.stream(ctx, strings.ToLower(msg.Value))
+		}
+
+		var cmd bbt.Cmd
+		cmd = func() bbt.Msg {
+			ids, err := fn(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				return ErrorMsg{
+					Err:   fmt.Errorf("%s stories: %w", strings.ToLower(msg.Value), err),
+					Time:  time.Now(),
+					Retry: cmd,
+				}
+			}
+
+			return ListMsg[[]int]{
+				Value: ids,
+				ctx:   ctx,
+				pane:  p,
+			}
+		}
+
+		return p, cmd
+	case ListMsg[time.Time]:
+		p.Cancel()
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+		p.requested = make(map[int]bool)
+
+		ctx, day := p.ctx, msg.Value
+		var cmd bbt.Cmd
+		cmd = func() bbt.Msg {
+			ids, err := hn.Front(ctx, day)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				return ErrorMsg{
+					Err:   fmt.Errorf("front page %s: %w", day.Format(time.DateOnly), err),
+					Time:  time.Now(),
+					Retry: cmd,
+				}
+			}
+
+			return ListMsg[[]int]{
+				Value: ids,
+				ctx:   ctx,
+				pane:  p,
+			}
+		}
+
+		return p, cmd
+	case ListMsg[[]int]:
+		if msg.ctx != nil && msg.ctx.Err() != nil {
+			return p, nil
+		}
+
+		if msg.next != nil {
+			return p, bbt.Batch(p.reorder(msg.Value), msg.next)
+		}
+
+		return p, p.set(msg.Value)
+	case ListMsg[*Story]:
+		if msg.ctx != nil && msg.ctx.Err() != nil {
+			return p, nil
+		}
+
+		items := p.model.Items()
+		if rank := msg.Value.Rank; rank >= len(items) || items[rank].(*Story).ID != msg.Value.ID {
+			// the story moved while it was loading
+			msg.Value.Rank = slices.IndexFunc(items, func(item list.Item) bool {
+				return item.(*Story).ID == msg.Value.ID
+			})
+		}
+
+		if rank := msg.Value.Rank; rank >= 0 {
+			if msg.Value.err != nil && items[rank].(*Story).loaded {
+				// keep showing the story if refreshing it failed
+				return p, Error(msg.Value.err, nil)
+			}
+
+			cmd := p.model.SetItem(rank, msg.Value)
+			if err := msg.Value.err; err != nil && !errors.Is(err, ErrUnavailable) && !errors.Is(err, ErrNotFound) {
+				cmd = bbt.Batch(cmd, Error(err, msg.retry))
+			}
+
+			return p, cmd
+		}
+
+		return p, nil
+	case UpdatesMsg:
+		changed := make(map[int]bool, len(msg.Value.Items))
+		for _, id := range msg.Value.Items {
+			changed[id] = true
+		}
+
+		var cmds []bbt.Cmd
+		for _, item := range p.model.Items() {
+			if story := item.(*Story); story.loaded && changed[story.ID] {
+				cmds = append(cmds, p.story(p.ctx, story.Rank, story.ID))
+			}
+		}
+
+		return p, bbt.Batch(cmds...)
+	case bbt.KeyMsg:
+		if p.chord != "" && !p.model.SettingFilter() {
+			chord := p.chord + " " + msg.String()
+			p.chord = ""
+
+			story, ok := p.model.SelectedItem().(*Story)
+			if !ok || !story.loaded {
+				return p, nil
+			}
+
+			switch chord {
+			case "o u":
+				return p, Open(p.config.Opener, story.Link())
+			case "o d":
+				return p, Open(p.config.Opener, ItemURL(story.ID))
+			case "y u":
+				return p, Yank(p.config.Clipboard, story.Link())
+			case "y d":
+				return p, Yank(p.config.Clipboard, ItemURL(story.ID))
+			}
+
+			return p, nil
+		}
+
+		switch msg.String() {
+		case "o", "y":
+			if p.model.SettingFilter() {
+				break
+			}
+
+			p.chord = msg.String()
+			return p, nil
+		case "enter":
+			if story, ok := p.model.SelectedItem().(*Story); ok && story.loaded {
+				return p, bbt.Sequence(
+					Activate("view"),
+					View(story),
+				)
+			}
+
+			return p, nil
+		case "u":
+			if p.model.SettingFilter() {
+				break
+			}
+
+			if story, ok := p.model.SelectedItem().(*Story); ok && story.By != "" {
+				return p, bbt.Sequence(
+					Activate("user"),
+					ShowUser(story.By),
+				)
+			}
+
+			return p, nil
+		case "r":
+			if p.model.SettingFilter() {
+				break
+			}
+
+			if story, ok := p.model.SelectedItem().(*Story); ok && story.err != nil {
+				return p, p.story(WithPriority(p.ctx, PriorityHigh), story.Rank, story.ID)
+			}
+
+			return p, nil
+		case "k", "up":
+			if p.model.Index() == 0 {
+				return p, Activate("header")
+			}
+		case "tab":
+			return p, Activate("toggle")
+		}
+	}
+
+	var cmd bbt.Cmd
+	p.model, cmd = p.model.Update(msg)
+	return p, bbt.Batch(cmd, p.load())
+}
+
+// Load replaces the list with ids in a new scope, cancelling anything
+// still loading.
+func (p *PaneList) Load(ids []int) bbt.Cmd {
+	p.Cancel()
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.model.ResetSelected()
+	return p.set(ids)
+}
+
+// set fills the list with placeholders for ids, so pagination reflects
+// every story, and fetches those in view.
+func (p *PaneList) set(ids []int) bbt.Cmd {
+	items := make([]list.Item, len(ids))
+	for i, id := range ids {
+		story := NewStory(i)
+		story.ID = id
+		items[i] = story
+	}
+
+	p.requested = make(map[int]bool)
+	return bbt.Batch(p.model.SetItems(items), p.load())
+}
+
+// reorder rearranges the list to match ids, e.g. after a streamed list
+// changes, keeping stories already loaded and the selected story, and
+// fetches those in view.
+func (p *PaneList) reorder(ids []int) bbt.Cmd {
+	stories := make(map[int]*Story, len(ids))
+	for _, item := range p.model.Items() {
+		story := item.(*Story)
+		stories[story.ID] = story
+	}
+
+	var selected int
+	if story, ok := p.model.SelectedItem().(*Story); ok {
+		selected = story.ID
+	}
+
+	index := -1
+	items := make([]list.Item, len(ids))
+	for i, id := range ids {
+		story, ok := stories[id]
+		if !ok {
+			story = NewStory(i)
+			story.ID = id
+		}
+
+		story.Rank = i
+		items[i] = story
+		if id == selected {
+			index = i
+		}
+	}
+
+	cmd := p.model.SetItems(items)
+	if index >= 0 && p.model.FilterState() == list.Unfiltered {
+		p.model.Select(index)
+	}
+
+	return bbt.Batch(cmd, p.load())
+}
+
+// stream follows the story list kind, reordering the list as it changes.
+func (p *PaneList) stream(ctx context.Context, kind string) bbt.Cmd {
+	var follow func(<-chan []int) bbt.Cmd
+	follow = func(ch <-chan []int) bbt.Cmd {
+		return func() bbt.Msg {
+			ids, ok := <-ch
+			if !ok {
+				return nil
+			}
+
+			return ListMsg[[]int]{
+				Value: ids,
+				ctx:   ctx,
+				pane:  p,
+				next:  follow(ch),
+			}
+		}
+	}
+
+	var cmd bbt.Cmd
+	cmd = func() bbt.Msg {
+		ch, err := p.hn.StreamStories(ctx, kind)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return ErrorMsg{
+				Err:   fmt.Errorf("%s stories: %w", kind, err),
+				Time:  time.Now(),
+				Retry: cmd,
+			}
+		}
+
+		return follow(ch)()
+	}
+
+	return cmd
+}
+
+// story fetches the story at rank, or a placeholder if it fails to load.
+func (p *PaneList) story(ctx context.Context, rank, id int) bbt.Cmd {
+	var cmd bbt.Cmd
+	cmd = func() bbt.Msg {
+		story, err := p.hn.Story(ctx, rank, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			story = NewStory(rank)
+			story.ID = id
+			story.err = err
+		}
+
+		return ListMsg[*Story]{
+			Value: story,
+			ctx:   ctx,
+			retry: cmd,
+			pane:  p,
+		}
+	}
+
+	return cmd
+}
+
+// load fetches stories on the current page, and those within prefetch
+// pages of it, which have not been requested yet. Stories on the current
+// page take priority over everything else.
+func (p *PaneList) load() bbt.Cmd {
+	items := p.model.VisibleItems()
+	start, end := p.model.Paginator.GetSliceBounds(len(items))
+
+	lo, hi := start-prefetch*p.model.Paginator.PerPage, end+prefetch*p.model.Paginator.PerPage
+	if lo < 0 {
+		lo = 0
+	}
+
+	if hi > len(items) {
+		hi = len(items)
+	}
+
+	var promote []int
+	var cmds []bbt.Cmd
+	for i := lo; i < hi; i++ {
+		story := items[i].(*Story)
+		visible := i >= start && i < end
+		if story.loaded || story.err != nil {
+			continue
+		} else if p.requested[story.ID] {
+			if visible {
+				promote = append(promote, story.ID)
+			}
+
+			continue
+		}
+
+		p.requested[story.ID] = true
+
+		ctx := WithPriority(p.ctx, PriorityLow)
+		if visible {
+			ctx = WithPriority(p.ctx, PriorityHigh)
+		}
+
+		cmds = append(cmds, p.story(ctx, story.Rank, story.ID))
+	}
+
+	p.hn.Prioritize(promote...)
+	return bbt.Batch(cmds...)
+}
+
+func (p *PaneList) View() string {
+	return p.style.Render(p.model.View())
+}
+
+func (p *PaneList) Size() (width, height int) {
+	h, v := p.style.GetFrameSize()
+	return p.model.Width() + h, p.model.Height() + v
+}
+
+func (p *PaneList) SetSize(width, height int) {
+	h, v := p.style.GetFrameSize()
+	p.model.SetSize(width-h, height-v)
+}
+
+func (p *PaneList) Activate() Pane {
+	return p
+}
+
+func (p *PaneList) Deactivate() {
+}
+
+type HeaderMsg int
+
+func Header(n int) bbt.Cmd {
+	return func() bbt.Msg {
 		return HeaderMsg(n)
 	}
 }
 
-type PaneHeader struct {
-	index         int
-	width, height int
-	active        bool
+type PaneHeader struct {
+	index         int
+	width, height int
+	active        bool
+
+	style lipgloss.Style
+	items []lipgloss.Style
+	funcs []func() bbt.Cmd
+}
+
+type PaneHeaderItem struct {
+	Name string
+	Func func() bbt.Cmd
+}
+
+func NewPaneHeader(items ...PaneHeaderItem) *PaneHeader {
+	pane := PaneHeader{
+		style: lipgloss.NewStyle().Margin(1, 2),
+	}
+
+	style := lipgloss.NewStyle().
+		Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
+
+	for _, item := range items {
+		pane.items = append(pane.items, style.Copy().SetString(item.Name))
+		pane.funcs = append(pane.funcs, item.Func)
+	}
+
+	return &pane
+}
+
+func (p *PaneHeader) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
+	switch msg := msg.(type) {
+	case HeaderMsg:
+		p.index = int(msg)
+		return p, p.funcs[int(msg)]()
+	case bbt.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			return p, Header(p.index)
+		case "h", "left":
+			p.index = mod(p.index-1, len(p.items))
+		case "l", "right":
+			p.index = mod(p.index+1, len(p.items))
+		case "j", "down", "tab":
+			return p, Activate("toggle")
+		}
+	}
+
+	return p, nil
+}
+
+func (p *PaneHeader) View() string {
+	var views []string
+	for i := range p.items {
+		state := p.items[i]
+		if i == p.index {
+			state = state.Copy().Underline(true)
+			if p.active {
+				state = state.Copy().Foreground(lipgloss.Color("#ff6600"))
+			}
+		}
+
+		if i < len(p.items)-1 {
+			state = state.Copy().MarginRight(2)
+		}
+
+		views = append(views, state.String())
+	}
+
+	var sb strings.Builder
+
+	left := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#ff6600")).
+		Bold(true).
+		Render("termhnal")
+	right := lipgloss.JoinHorizontal(lipgloss.Top, views...)
+
+	sb.WriteString(left)
+	if pad := p.width - lipgloss.Width(left) - lipgloss.Width(right); pad > 0 {
+		sb.WriteString(strings.Repeat(" ", pad))
+	}
+
+	sb.WriteString(right)
+	return p.style.Render(sb.String())
+}
+
+func (p *PaneHeader) Size() (width, height int) {
+	_, v := p.style.GetFrameSize()
+	return 0, v + 1
+}
+
+func (p *PaneHeader) SetSize(width, height int) {
+	h, v := p.style.GetFrameSize()
+	p.width, p.height = width-h, height-v
+}
+
+func (p *PaneHeader) Activate() Pane {
+	p.active = true
+	return p
+}
+
+func (p *PaneHeader) Deactivate() {
+	p.active = false
+}
+
+// PanePrompt asks for a line of input in place of the footer.
+type PanePrompt struct {
+	input textinput.Model
+	style lipgloss.Style
+
+	// submit acts on the input, or reports why it is invalid
+	submit func(string) (bbt.Cmd, error)
+	err    error
+
+	styleError lipgloss.Style
+}
+
+func NewPanePrompt(prompt, placeholder string, submit func(string) (bbt.Cmd, error)) *PanePrompt {
+	input := textinput.New()
+	input.Prompt = prompt
+	input.Placeholder = placeholder
+	input.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff6600")).Bold(true)
+	return &PanePrompt{
+		input:  input,
+		submit: submit,
+		style:  lipgloss.NewStyle().Margin(1, 2, 0),
+		styleError: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ff0000")),
+	}
+}
+
+func (p *PanePrompt) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
+	if msg, ok := msg.(bbt.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			cmd, err := p.submit(p.input.Value())
+			if p.err = err; err != nil {
+				return p, nil
+			}
+
+			p.input.Reset()
+			return p, bbt.Sequence(Activate("list"), cmd)
+		case "esc":
+			p.err = nil
+			p.input.Reset()
+			return p, Activate("list")
+		}
+	}
+
+	var cmd bbt.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p *PanePrompt) View() string {
+	if p.err != nil {
+		// make room for the error after the input
+		err := p.styleError.Render(p.err.Error())
+		input := p.input
+		input.Width -= lipgloss.Width(err) + 1
+		return p.style.Render(fmt.Sprintf("%s %s", input.View(), err))
+	}
+
+	return p.style.Render(p.input.View())
+}
+
+func (p *PanePrompt) Size() (width, height int) {
+	_, v := p.style.GetFrameSize()
+	return 0, v + 1
+}
+
+func (p *PanePrompt) SetSize(width, height int) {
+	h, _ := p.style.GetFrameSize()
+	p.input.Width = width - h - lipgloss.Width(p.input.Prompt) - 1
+}
+
+func (p *PanePrompt) Activate() Pane {
+	p.input.Focus()
+	return p
+}
+
+func (p *PanePrompt) Deactivate() {
+	p.input.Blur()
+}
+
+type PaneFooter struct {
+	width, height int
+	style         lipgloss.Style
+	left, right   func() string
+}
+
+func NewPaneFooter(left, right func() string) *PaneFooter {
+	return &PaneFooter{
+		left:  left,
+		right: right,
+		style: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}).
+			Faint(true).
+			Margin(1, 2, 0),
+	}
+}
+
+func (p *PaneFooter) Update(bbt.Msg) (Pane, bbt.Cmd) {
+	return p, nil
+}
+
+func (p *PaneFooter) View() string {
+	var sb strings.Builder
+
+	left := p.left()
+	right := p.right()
+
+	sb.WriteString(left)
+	if fill := p.width - lipgloss.Width(left) - lipgloss.Width(right); fill > 0 {
+		sb.WriteString(strings.Repeat(" ", fill))
+	}
+
+	sb.WriteString(right)
+	return p.style.Render(sb.String())
+}
+
+func (p *PaneFooter) Size() (width, height int) {
+	_, v := p.style.GetFrameSize()
+	return 0, v + 1
+}
+
+func (p *PaneFooter) SetSize(width, height int) {
+	h, v := p.style.GetFrameSize()
+	p.width, p.height = width-h, height-v
+}
+
+func (p *PaneFooter) Activate() Pane {
+	return p
+}
+
+func (p *PaneFooter) Deactivate() {
+}
+
+type UserMsg struct {
+	ID    string
+	Value *User
+
+	// ctx is the scope the value was fetched in. Values from a cancelled
+	// scope are stale and dropped.
+	ctx context.Context
+}
+
+// ShowUser loads the profile of the user id.
+func ShowUser(id string) bbt.Cmd {
+	return func() bbt.Msg {
+		return UserMsg{
+			ID: id,
+		}
+	}
+}
+
+type PaneUser struct {
+	*User
+	hn     *HN
+	config *Config
+	list   *PaneList
+	style  lipgloss.Style
+
+	width, height int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	styleTitle       lipgloss.Style
+	styleDescription lipgloss.Style
+}
+
+func NewPaneUser(hn *HN, config *Config) *PaneUser {
+	return &PaneUser{
+		hn:     hn,
+		config: config,
+		list:   NewPaneList(hn, config),
+		style:  lipgloss.NewStyle().Margin(1, 2, 0),
+		ctx:    context.Background(),
+		cancel: func() {},
+		styleTitle: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ff6600")).
+			Bold(true),
+		styleDescription: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}),
+	}
+}
+
+// Cancel aborts loading the current user and their submissions.
+func (p *PaneUser) Cancel() {
+	p.cancel()
+	p.list.Cancel()
+}
+
+func (p *PaneUser) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
+	switch msg := msg.(type) {
+	case UserMsg:
+		if msg.Value == nil {
+			p.Cancel()
+			p.ctx, p.cancel = context.WithCancel(context.Background())
+			p.User = &User{ID: msg.ID}
+			p.resize()
+
+			ctx, id := p.ctx, msg.ID
+			var cmd bbt.Cmd
+			cmd = func() bbt.Msg {
+				user, err := p.hn.User(ctx, id)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+
+					return ErrorMsg{
+						Err:   fmt.Errorf("user %s: %w", id, err),
+						Time:  time.Now(),
+						Retry: cmd,
+					}
+				}
+
+				return UserMsg{
+					ID:    id,
+					Value: user,
+					ctx:   ctx,
+				}
+			}
+
+			return p, bbt.Batch(p.list.Load(nil), cmd)
+		} else if msg.ctx != nil && msg.ctx.Err() != nil {
+			return p, nil
+		}
+
+		p.User = msg.Value
+		p.resize()
+		return p, p.list.Load(msg.Value.Submitted)
+	}
+
+	_, cmd := p.list.Update(msg)
+	return p, cmd
+}
+
+func (p *PaneUser) info() string {
+	if p.User == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(p.styleTitle.Render(p.User.ID))
+	if p.Created > 0 {
+		fmt.Fprintf(&sb, "\n%s", p.styleDescription.Render(fmt.Sprintf(
+			"%d karma | joined %s (%s) | %d submissions",
+			p.Karma,
+			time.Unix(p.Created, 0).Format("January 2, 2006"),
+			humanize(time.Unix(p.Created, 0)),
+			len(p.Submitted),
+		)))
+	}
+
+	if p.About != "" {
+		fmt.Fprintf(&sb, "\n\n%s", RenderHTML(p.About, p.width, lipgloss.NewStyle(), p.config.Hyperlinks))
+	}
+
+	return sb.String()
+}
+
+// resize fits the list of submissions below the user's details.
+func (p *PaneUser) resize() {
+	height := p.height - lipgloss.Height(p.style.Render(p.info()))
+	if height < 0 {
+		height = 0
+	}
+
+	p.list.SetSize(p.width+p.style.GetHorizontalFrameSize(), height)
+}
+
+func (p *PaneUser) View() string {
+	return lipgloss.JoinVertical(lipgloss.Left, p.style.Render(p.info()), p.list.View())
+}
+
+func (p *PaneUser) Size() (width, height int) {
+	h, _ := p.style.GetFrameSize()
+	return p.width + h, p.height
+}
+
+func (p *PaneUser) SetSize(width, height int) {
+	h, _ := p.style.GetFrameSize()
+	p.width, p.height = width-h, height
+	p.resize()
+}
+
+func (p *PaneUser) Activate() Pane {
+	return p
+}
+
+func (p *PaneUser) Deactivate() {
+}
+
+// SearchMsg requests a search for Query, or carries its results in Value.
+type SearchMsg struct {
+	Query SearchQuery
+	Value *SearchResult
+
+	// ctx is the scope the value was fetched in. Values from a cancelled
+	// scope are stale and dropped.
+	ctx context.Context
+}
+
+// ShowSearch searches for q.
+func ShowSearch(q SearchQuery) bbt.Cmd {
+	return func() bbt.Msg {
+		return SearchMsg{
+			Query: q,
+		}
+	}
+}
+
+// searchHits is the number of results fetched for each search.
+const searchHits = 100
+
+type PaneSearch struct {
+	*SearchResult
+	hn    *HN
+	input textinput.Model
+	list  *PaneList
+	style lipgloss.Style
+
+	// err is why the query in the input could not be parsed
+	err error
+
+	width, height int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	styleDescription lipgloss.Style
+	styleError       lipgloss.Style
+}
+
+func NewPaneSearch(hn *HN, config *Config) *PaneSearch {
+	input := textinput.New()
+	input.Prompt = "search: "
+	input.Placeholder = "text author:pg tag:story points>100 after:2006-01-02 before:2006-01-02 sort:date"
+	input.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff6600")).Bold(true)
+	return &PaneSearch{
+		hn:     hn,
+		input:  input,
+		list:   NewPaneList(hn, config),
+		style:  lipgloss.NewStyle().Margin(1, 2, 0),
+		ctx:    context.Background(),
+		cancel: func() {},
+		styleDescription: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}),
+		styleError: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ff0000")),
+	}
+}
+
+// Cancel aborts the search in progress and loading its results.
+func (p *PaneSearch) Cancel() {
+	p.cancel()
+	p.list.Cancel()
+}
 
-	style lipgloss.Style
-	items []lipgloss.Style
-	funcs []func() bbt.Cmd
+// Typing reports whether keys go to the search input.
+func (p *PaneSearch) Typing() bool {
+	return p.input.Focused()
 }
 
-type PaneHeaderItem struct {
-	Name string
-	Func func() bbt.Cmd
+func (p *PaneSearch) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
+	switch msg := msg.(type) {
+	case SearchMsg:
+		if msg.Value == nil {
+			p.Cancel()
+			p.ctx, p.cancel = context.WithCancel(context.Background())
+			p.SearchResult = nil
+			p.resize()
+
+			ctx, q := p.ctx, msg.Query
+			if q.HitsPerPage == 0 {
+				q.HitsPerPage = searchHits
+			}
+
+			var cmd bbt.Cmd
+			cmd = func() bbt.Msg {
+				result, err := p.hn.Search(ctx, q)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+
+					return ErrorMsg{
+						Err:   fmt.Errorf("search: %w", err),
+						Time:  time.Now(),
+						Retry: cmd,
+					}
+				}
+
+				return SearchMsg{
+					Query: q,
+					Value: result,
+					ctx:   ctx,
+				}
+			}
+
+			return p, bbt.Batch(p.list.Load(nil), cmd)
+		} else if msg.ctx != nil && msg.ctx.Err() != nil {
+			return p, nil
+		}
+
+		p.SearchResult = msg.Value
+		p.input.Blur()
+		p.resize()
+		return p, p.list.Load(msg.Value.IDs())
+	case bbt.KeyMsg:
+		if p.input.Focused() {
+			switch msg.String() {
+			case "enter":
+				q, err := ParseSearchQuery(p.input.Value())
+				p.err = err
+				p.resize()
+				if err != nil {
+					return p, nil
+				}
+
+				return p, ShowSearch(q)
+			case "esc", "down", "tab":
+				if len(p.list.model.Items()) > 0 {
+					p.input.Blur()
+					return p, nil
+				} else if msg.String() == "esc" {
+					p.Cancel()
+					return p, Activate("back")
+				}
+
+				return p, nil
+			}
+
+			var cmd bbt.Cmd
+			p.input, cmd = p.input.Update(msg)
+			return p, cmd
+		}
+
+		if !p.list.model.SettingFilter() {
+			switch msg.String() {
+			case "/":
+				return p, p.input.Focus()
+			case "k", "up":
+				if p.list.model.Index() == 0 {
+					return p, p.input.Focus()
+				}
+			}
+		}
+
+		_, cmd := p.list.Update(msg)
+		return p, cmd
+	}
+
+	var cmds []bbt.Cmd
+	var cmd bbt.Cmd
+	p.input, cmd = p.input.Update(msg)
+	cmds = append(cmds, cmd)
+
+	_, cmd = p.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return p, bbt.Batch(cmds...)
 }
 
-func NewPaneHeader(items ...PaneHeaderItem) *PaneHeader {
-	pane := PaneHeader{
-		style: lipgloss.NewStyle().Margin(1, 2),
+func (p *PaneSearch) info() string {
+	var sb strings.Builder
+	sb.WriteString(p.input.View())
+	if p.err != nil {
+		fmt.Fprintf(&sb, "\n%s", p.styleError.Render(p.err.Error()))
+	} else if p.SearchResult != nil {
+		fmt.Fprintf(&sb, "\n%s", p.styleDescription.Render(fmt.Sprintf("%d results", p.Total)))
 	}
 
-	style := lipgloss.NewStyle().
-		Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
+	return sb.String()
+}
 
-	for _, item := range items {
-		pane.items = append(pane.items, style.Copy().SetString(item.Name))
-		pane.funcs = append(pane.funcs, item.Func)
+// resize fits the list of results below the input.
+func (p *PaneSearch) resize() {
+	p.input.Width = p.width - lipgloss.Width(p.input.Prompt) - 1
+	height := p.height - lipgloss.Height(p.style.Render(p.info()))
+	if height < 0 {
+		height = 0
 	}
 
-	return &pane
+	p.list.SetSize(p.width+p.style.GetHorizontalFrameSize(), height)
 }
 
-func (p *PaneHeader) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
+func (p *PaneSearch) View() string {
+	return lipgloss.JoinVertical(lipgloss.Left, p.style.Render(p.info()), p.list.View())
+}
+
+func (p *PaneSearch) Size() (width, height int) {
+	h, _ := p.style.GetFrameSize()
+	return p.width + h, p.height
+}
+
+func (p *PaneSearch) SetSize(width, height int) {
+	h, _ := p.style.GetFrameSize()
+	p.width, p.height = width-h, height
+	p.resize()
+}
+
+// Activate focuses the input until there are results to browse.
+func (p *PaneSearch) Activate() Pane {
+	if p.SearchResult == nil {
+		p.input.Focus()
+	}
+
+	return p
+}
+
+func (p *PaneSearch) Deactivate() {
+	p.input.Blur()
+}
+
+// ArticleMsg requests the page linked from Story, or carries it in Value.
+type ArticleMsg struct {
+	Story *Story
+	Value *Article
+
+	// ctx is the scope the value was fetched in. Values from a cancelled
+	// scope are stale and dropped.
+	ctx context.Context
+
+	// err is why the page could not be read, and retry fetches it again
+	err   error
+	retry bbt.Cmd
+}
+
+// ShowArticle loads the page linked from story in reader mode.
+func ShowArticle(story *Story) bbt.Cmd {
+	return func() bbt.Msg {
+		return ArticleMsg{
+			Story: story,
+		}
+	}
+}
+
+type PaneReader struct {
+	*Article
+	story  *Story
+	hn     *HN
+	config *Config
+	style  lipgloss.Style
+
+	// err is why the article could not be read
+	err error
+
+	// lines holds the rendered article, offset is the first line in view
+	lines  []string
+	offset int
+
+	// chord is the first key of a two key binding, e.g. o in o u
+	chord string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	retry  bbt.Cmd
+
+	styleTitle       lipgloss.Style
+	styleDescription lipgloss.Style
+	styleError       lipgloss.Style
+}
+
+func NewPaneReader(hn *HN, config *Config) *PaneReader {
+	return &PaneReader{
+		hn:     hn,
+		config: config,
+		style:  lipgloss.NewStyle().Margin(1, 2),
+		ctx:    context.Background(),
+		cancel: func() {},
+		styleTitle: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"}).
+			Bold(true),
+		styleDescription: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}),
+		styleError: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ff0000")),
+	}
+}
+
+// Cancel aborts loading the current article.
+func (p *PaneReader) Cancel() {
+	p.cancel()
+}
+
+func (p *PaneReader) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
 	switch msg := msg.(type) {
-	case HeaderMsg:
-		p.index = int(msg)
-		return p, p.funcs[int(msg)]()
+	case ArticleMsg:
+		if msg.ctx == nil {
+			p.Cancel()
+			p.ctx, p.cancel = context.WithCancel(context.Background())
+			p.story, p.Article, p.err, p.retry = msg.Story, nil, nil, nil
+			p.offset = 0
+			p.Render()
+
+			ctx, story := p.ctx, msg.Story
+			var cmd bbt.Cmd
+			cmd = func() bbt.Msg {
+				article, err := p.hn.Article(ctx, story.URL)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+
+					err = fmt.Errorf("article %d: %w", story.ID, err)
+				}
+
+				return ArticleMsg{
+					Story: story,
+					Value: article,
+					ctx:   ctx,
+					err:   err,
+					retry: cmd,
+				}
+			}
+
+			return p, cmd
+		} else if msg.ctx.Err() != nil {
+			return p, nil
+		}
+
+		p.Article, p.err, p.retry = msg.Value, msg.err, msg.retry
+		p.Render()
+		if msg.err != nil {
+			return p, Error(msg.err, msg.retry)
+		}
+
+		return p, nil
 	case bbt.KeyMsg:
+		if p.chord != "" {
+			chord := p.chord + " " + msg.String()
+			p.chord = ""
+			switch chord {
+			case "o u":
+				return p, Open(p.config.Opener, p.story.Link())
+			case "o d":
+				return p, Open(p.config.Opener, ItemURL(p.story.ID))
+			case "y u":
+				return p, Yank(p.config.Clipboard, p.story.Link())
+			case "y d":
+				return p, Yank(p.config.Clipboard, ItemURL(p.story.ID))
+			}
+
+			return p, nil
+		}
+
 		switch msg.String() {
-		case "enter":
-			return p, Header(p.index)
-		case "h", "left":
-			p.index = mod(p.index-1, len(p.items))
-		case "l", "right":
-			p.index = mod(p.index+1, len(p.items))
-		case "j", "down", "tab":
+		case "o", "y":
+			if p.story != nil {
+				p.chord = msg.String()
+			}
+		case "r":
+			if p.err != nil && p.retry != nil {
+				p.err = nil
+				p.Render()
+				return p, p.retry
+			}
+		case "k", "up":
+			if p.offset == 0 {
+				return p, Activate("header")
+			}
+
+			p.scroll(p.offset - 1)
+		case "j", "down":
+			p.scroll(p.offset + 1)
+		case "h", "left", "pgup", "b":
+			p.scroll(p.offset - p.style.GetHeight())
+		case "l", "right", "pgdown", "f", " ":
+			p.scroll(p.offset + p.style.GetHeight())
+		case "g", "home":
+			p.scroll(0)
+		case "G", "end":
+			p.scroll(len(p.lines))
+		case "tab":
 			return p, Activate("toggle")
 		}
+	case bbt.WindowSizeMsg:
+		p.Render()
 	}
 
 	return p, nil
 }
 
-func (p *PaneHeader) View() string {
-	var views []string
-	for i := range p.items {
-		state := p.items[i]
-		if i == p.index {
-			state = state.Copy().Underline(true)
-			if p.active {
-				state = state.Copy().Foreground(lipgloss.Color("#ff6600"))
-			}
-		}
+// Render lays out the story's title and link above the article.
+func (p *PaneReader) Render() {
+	if p.story == nil {
+		p.lines = nil
+		return
+	}
 
-		if i < len(p.items)-1 {
-			state = state.Copy().MarginRight(2)
-		}
+	width := p.style.GetWidth()
 
-		views = append(views, state.String())
+	title := strings.TrimPrefix(p.story.Title(), fmt.Sprintf("%d. ", p.story.Rank+1))
+	if p.Article != nil && p.Article.Title != "" {
+		title = p.Article.Title
 	}
 
 	var sb strings.Builder
+	fmt.Fprintln(&sb, p.styleTitle.Copy().Width(width).Render(title))
+	fmt.Fprint(&sb, p.config.Hyperlinks.Link(p.story.URL, p.styleDescription.Copy().Underline(true).Italic(true).Width(width).Render(p.story.URL)))
+
+	switch {
+	case p.err != nil:
+		fmt.Fprint(&sb, "\n\n", p.styleError.Copy().Width(width).Render(p.err.Error()))
+		fmt.Fprint(&sb, "\n", p.styleDescription.Render("r to retry | o u to open in a browser"))
+	case p.Article == nil:
+		fmt.Fprint(&sb, "\n\n", p.styleDescription.Render("loading..."))
+	default:
+		fmt.Fprint(&sb, "\n\n", p.Article.Render(width, p.config.Hyperlinks))
+	}
 
-	left := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#ff6600")).
-		Bold(true).
-		Render("termhnal")
-	right := lipgloss.JoinHorizontal(lipgloss.Top, views...)
+	p.lines = strings.Split(sb.String(), "\n")
+	p.scroll(p.offset)
+}
 
-	sb.WriteString(left)
-	if pad := p.width - lipgloss.Width(left) - lipgloss.Width(right); pad > 0 {
-		sb.WriteString(strings.Repeat(" ", pad))
+// scroll moves the view to start at line offset, keeping it within the
+// article.
+func (p *PaneReader) scroll(offset int) {
+	if bottom := len(p.lines) - p.style.GetHeight(); offset > bottom {
+		offset = bottom
 	}
 
-	sb.WriteString(right)
-	return p.style.Render(sb.String())
+	if offset < 0 {
+		offset = 0
+	}
+
+	p.offset = offset
 }
 
-func (p *PaneHeader) Size() (width, height int) {
-	_, v := p.style.GetFrameSize()
-	return 0, v + 1
+// ScrollPercent is how far through the article the view is.
+func (p *PaneReader) ScrollPercent() float64 {
+	bottom := len(p.lines) - p.style.GetHeight()
+	if bottom <= 0 {
+		return 1
+	}
+
+	return float64(p.offset) / float64(bottom)
 }
 
-func (p *PaneHeader) SetSize(width, height int) {
+func (p *PaneReader) View() string {
+	end := p.offset + p.style.GetHeight()
+	if end > len(p.lines) {
+		end = len(p.lines)
+	}
+
+	return p.style.Render(strings.Join(p.lines[p.offset:end], "\n"))
+}
+
+func (p *PaneReader) Size() (width, height int) {
 	h, v := p.style.GetFrameSize()
-	p.width, p.height = width-h, height-v
+	return p.style.GetWidth() + h, p.style.GetHeight() + v
 }
 
-func (p *PaneHeader) Activate() Pane {
-	p.active = true
+func (p *PaneReader) SetSize(width, height int) {
+	h, v := p.style.GetFrameSize()
+	p.style = p.style.Width(width - h).Height(height - v)
+}
+
+func (p *PaneReader) Activate() Pane {
 	return p
 }
 
-func (p *PaneHeader) Deactivate() {
-	p.active = false
+func (p *PaneReader) Deactivate() {
 }
 
-type PaneFooter struct {
-	width, height int
-	style         lipgloss.Style
-	left, right   func() string
+type PaneErrors struct {
+	log   *ErrorLog
+	model list.Model
+	style lipgloss.Style
 }
 
-func NewPaneFooter(left, right func() string) *PaneFooter {
-	return &PaneFooter{
-		left:  left,
-		right: right,
-		style: lipgloss.NewStyle().
-			Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}).
-			Faint(true).
-			Margin(1, 2, 0),
+func NewPaneErrors(log *ErrorLog) *PaneErrors {
+	color := lipgloss.Color("#ff6600")
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(color).BorderLeftForeground(color)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedTitle.Copy().Faint(true)
+
+	model := list.New([]list.Item{}, delegate, 0, 0)
+	model.SetShowHelp(false)
+	model.SetShowStatusBar(false)
+	model.SetShowTitle(false)
+	model.SetShowPagination(false)
+	model.SetFilteringEnabled(false)
+	model.SetStatusBarItemName("error", "errors")
+	return &PaneErrors{
+		log:   log,
+		model: model,
+		style: lipgloss.NewStyle().Margin(1, 2),
 	}
 }
 
-func (p *PaneFooter) Update(bbt.Msg) (Pane, bbt.Cmd) {
-	return p, nil
+// Refresh reloads the list from the error log.
+func (p *PaneErrors) Refresh() bbt.Cmd {
+	items := make([]list.Item, len(p.log.errors))
+	for i := range p.log.errors {
+		items[i] = p.log.errors[i]
+	}
+
+	return p.model.SetItems(items)
 }
 
-func (p *PaneFooter) View() string {
-	var sb strings.Builder
+func (p *PaneErrors) Update(msg bbt.Msg) (Pane, bbt.Cmd) {
+	switch msg := msg.(type) {
+	case ErrorMsg:
+		return p, p.Refresh()
+	case bbt.KeyMsg:
+		switch msg.String() {
+		case "r", "enter":
+			i := p.model.Index()
+			if i < len(p.log.errors) && p.log.errors[i].Retry != nil {
+				retry := p.log.errors[i].Retry
+				p.log.Remove(i)
+				return p, bbt.Batch(p.Refresh(), retry)
+			}
 
-	left := p.left()
-	right := p.right()
+			return p, nil
+		case "k", "up":
+			if p.model.Index() == 0 {
+				return p, Activate("header")
+			}
+		case "tab":
+			return p, Activate("toggle")
+		}
+	}
 
-	sb.WriteString(left)
-	if fill := p.width - lipgloss.Width(left) - lipgloss.Width(right); fill > 0 {
-		sb.WriteString(strings.Repeat(" ", fill))
+	var cmd bbt.Cmd
+	p.model, cmd = p.model.Update(msg)
+	return p, cmd
+}
+
+func (p *PaneErrors) View() string {
+	if len(p.log.errors) == 0 {
+		return p.style.Render(lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#a49fa5", Dark: "#777777"}).
+			Width(p.model.Width()).
+			Height(p.model.Height()).
+			Render("No errors"))
 	}
 
-	sb.WriteString(right)
-	return p.style.Render(sb.String())
+	return p.style.Render(p.model.View())
 }
 
-func (p *PaneFooter) Size() (width, height int) {
-	_, v := p.style.GetFrameSize()
-	return 0, v + 1
+func (p *PaneErrors) Size() (width, height int) {
+	h, v := p.style.GetFrameSize()
+	return p.model.Width() + h, p.model.Height() + v
 }
 
-func (p *PaneFooter) SetSize(width, height int) {
+func (p *PaneErrors) SetSize(width, height int) {
 	h, v := p.style.GetFrameSize()
-	p.width, p.height = width-h, height-v
+	p.model.SetSize(width-h, height-v)
 }
 
-func (p *PaneFooter) Activate() Pane {
+func (p *PaneErrors) Activate() Pane {
+	p.Refresh()
 	return p
 }
 
-func (p *PaneFooter) Deactivate() {
+func (p *PaneErrors) Deactivate() {
 }
 
 func mod(a, b int) int {
@@ -0,0 +1,563 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	bbt "github.com/charmbracelet/bubbletea"
+)
+
+func TestPaneListCancelsStaleLoads(t *testing.T) {
+	p := NewPaneList(newTestHN(t), &Config{})
+
+	p.Update(ListMsg[string]{Value: "top"})
+	top := p.ctx
+
+	p.Update(ListMsg[string]{Value: "new"})
+	if top.Err() != context.Canceled {
+		t.Fatalf("top load err = %v, want %v", top.Err(), context.Canceled)
+	}
+
+	if _, cmd := p.Update(ListMsg[[]int]{Value: []int{1, 2}, ctx: top}); cmd != nil {
+		t.Error("stale IDs were fetched")
+	}
+
+	p.Update(ListMsg[[]int]{Value: []int{1, 2}, ctx: p.ctx})
+
+	stale := NewStory(0)
+	stale.ID, stale.loaded = 1, true
+	p.Update(ListMsg[*Story]{Value: stale, ctx: top})
+	if story := p.model.Items()[0].(*Story); story.loaded {
+		t.Error("the stale story replaced its placeholder")
+	}
+
+	fresh := NewStory(0)
+	fresh.ID, fresh.loaded = 1, true
+	p.Update(ListMsg[*Story]{Value: fresh, ctx: p.ctx})
+	if story := p.model.Items()[0].(*Story); !story.loaded {
+		t.Error("the new story did not replace its placeholder")
+	}
+
+	p.Update(ListMsg[string]{Value: "clear"})
+	if p.ctx.Err() != context.Canceled {
+		t.Errorf("new load err = %v after clear, want %v", p.ctx.Err(), context.Canceled)
+	}
+}
+
+func TestPaneListLoadsPages(t *testing.T) {
+	p := NewPaneList(newTestHN(t), &Config{})
+	p.SetSize(80, 24)
+
+	ids := make([]int, 100)
+	for i := range ids {
+		ids[i] = 1000 + i
+	}
+
+	p.Update(ListMsg[[]int]{Value: ids, ctx: p.ctx})
+
+	// every ID gets a placeholder so the pages cover the whole list
+	if n := len(p.model.Items()); n != len(ids) {
+		t.Fatalf("list has %d items, want %d", n, len(ids))
+	}
+
+	perPage := p.model.Paginator.PerPage
+	if want := (len(ids) + perPage - 1) / perPage; p.model.Paginator.TotalPages != want {
+		t.Errorf("TotalPages = %d, want %d", p.model.Paginator.TotalPages, want)
+	}
+
+	if title := p.model.Items()[0].(*Story).Title(); title != "1. loading..." {
+		t.Errorf("placeholder title = %q, want 1. loading...", title)
+	}
+
+	requested := func() (lo, hi int) {
+		lo, hi = len(ids), 0
+		for id := range p.requested {
+			rank := id - ids[0]
+			if rank < lo {
+				lo = rank
+			}
+
+			if rank >= hi {
+				hi = rank + 1
+			}
+		}
+
+		return lo, hi
+	}
+
+	// the first page and one page of prefetch
+	if lo, hi := requested(); lo != 0 || hi != 2*perPage || len(p.requested) != hi {
+		t.Errorf("requested ranks [%d, %d) of %d, want [0, %d)", lo, hi, len(p.requested), 2*perPage)
+	}
+
+	p.Update(bbt.KeyMsg{Type: bbt.KeyRight})
+	if lo, hi := requested(); lo != 0 || hi != 3*perPage || len(p.requested) != hi {
+		t.Errorf("requested ranks [%d, %d) of %d on page 2, want [0, %d)", lo, hi, len(p.requested), 3*perPage)
+	}
+
+	story := NewStory(1)
+	story.ID, story.loaded = ids[1], true
+	p.Update(ListMsg[*Story]{Value: story, ctx: p.ctx})
+	if got := p.model.Items()[1].(*Story); got != story {
+		t.Error("the story did not replace its placeholder")
+	}
+
+	other := NewStory(2)
+	other.ID, other.loaded = 1, true
+	p.Update(ListMsg[*Story]{Value: other, ctx: p.ctx})
+	if got := p.model.Items()[2].(*Story); got.loaded {
+		t.Error("a story replaced the placeholder of another ID")
+	}
+}
+
+func TestPaneViewCancelsStaleStory(t *testing.T) {
+	p := NewPaneView(newTestHN(t), &Config{})
+
+	first := NewStory(0)
+	first.ID = 1
+	p.Update(ViewMsg[*Story]{Value: first})
+	ctx := p.ctx
+
+	second := NewStory(0)
+	second.ID = 2
+	p.Update(ViewMsg[*Story]{Value: second})
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("first story err = %v, want %v", ctx.Err(), context.Canceled)
+	}
+
+	stale := NewComment(0)
+	stale.ID, stale.Kids = 3, []int{4}
+	if _, cmd := p.Update(ViewMsg[*Comment]{Value: stale, ctx: ctx}); cmd != nil {
+		t.Error("replies of a stale comment were fetched")
+	}
+
+	p.Cancel()
+	if p.ctx.Err() != context.Canceled {
+		t.Errorf("second story err = %v after Cancel, want %v", p.ctx.Err(), context.Canceled)
+	}
+}
+
+func TestPaneUser(t *testing.T) {
+	p := NewPaneUser(newTestHN(t), &Config{})
+	p.SetSize(80, 24)
+
+	p.Update(UserMsg{ID: "pg"})
+	ctx := p.ctx
+	if p.User == nil || p.User.ID != "pg" {
+		t.Fatalf("user = %+v while loading, want pg", p.User)
+	}
+
+	p.Update(UserMsg{ID: "pg"})
+	if _, cmd := p.Update(UserMsg{ID: "pg", Value: &User{ID: "stale"}, ctx: ctx}); cmd != nil || p.User.ID != "pg" {
+		t.Errorf("user = %+v, want the stale load dropped", p.User)
+	}
+
+	p.Update(UserMsg{
+		ID: "pg",
+		Value: &User{
+			ID:        "pg",
+			Created:   time.Now().Add(-48 * time.Hour).Unix(),
+			Karma:     42,
+			About:     "<i>Bug</i> fixer.<p>Essays at <a href=\"http://paulgraham.com\">paulgraham.com</a>",
+			Submitted: []int{3, 2, 1},
+		},
+		ctx: p.ctx,
+	})
+
+	if n := len(p.list.model.Items()); n != 3 {
+		t.Errorf("list has %d submissions, want 3", n)
+	}
+
+	comment := NewStory(0)
+	comment.ID, comment.Type, comment.By, comment.Text, comment.loaded = 3, "comment", "pg", "<p>a reply", true
+	p.Update(ListMsg[*Story]{Value: comment, ctx: p.list.ctx})
+
+	view := p.View()
+	for _, want := range []string{"pg", "42 karma", "2 days ago", "3 submissions", "Bug fixer.", "paulgraham.com", "1. a reply", "by pg"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() = %q, want it to contain %q", view, want)
+		}
+	}
+}
+
+func TestPaneViewPoll(t *testing.T) {
+	p := NewPaneView(newTestHN(t), &Config{})
+	p.SetSize(44, 24)
+
+	story := NewStory(0)
+	story.ID, story.Item.Title, story.Type, story.loaded = 1, "poll", "poll", true
+	story.Parts = []int{5, 6, 7, 8}
+	for i, tt := range []struct {
+		text  string
+		score int
+	}{{"most", 10}, {"half", 5}, {"none", 0}} {
+		option := NewPollOpt(i)
+		option.ID, option.Text, option.Score = story.Parts[i], tt.text, tt.score
+		story.AddOption(option)
+	}
+
+	failed := NewPollOpt(3)
+	failed.ID, failed.err = 8, ErrServer
+	story.AddOption(failed)
+
+	comment := NewComment(0)
+	comment.ID, comment.Parent, comment.By, comment.Text = 2, 1, "bob", "a comment"
+	story.AddComment(comment)
+
+	p.Story = story
+	p.Render()
+
+	bars := make(map[string]int)
+	lines := strings.Split(p.poll(story), "\n")
+	for i, line := range lines {
+		for _, text := range []string{"most", "half", "none"} {
+			if strings.TrimSpace(line) == text && i+1 < len(lines) {
+				bars[text] = strings.Count(lines[i+1], "█")
+			}
+		}
+	}
+
+	width := p.style.GetWidth()
+	if want := width - len(" 10 points"); bars["most"] != want {
+		t.Errorf("most popular bar is %d wide, want %d", bars["most"], want)
+	}
+
+	if want := (width - len(" 5 points")) / 2; bars["half"] != want {
+		t.Errorf("half as popular bar is %d wide, want %d", bars["half"], want)
+	}
+
+	if bars["none"] != 0 {
+		t.Errorf("unpopular bar is %d wide, want 0", bars["none"])
+	}
+
+	view := p.View()
+	for _, want := range []string{"10 points", "5 points", "0 points", "[failed to load]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() = %q, want it to contain %q", view, want)
+		}
+	}
+
+	if strings.Index(view, "most") > strings.Index(view, "a comment") {
+		t.Errorf("View() = %q, want the poll above the comments", view)
+	}
+}
+
+// newThreadPaneView shows a story with this thread, and 8 still loading:
+//
+//	2
+//	├ 4
+//	│ └ 6
+//	└ 5
+//	3
+//	└ 7
+func newThreadPaneView(t *testing.T) *PaneView {
+	t.Helper()
+
+	p := NewPaneView(newTestHN(t), &Config{})
+	p.SetSize(80, 100)
+
+	story := NewStory(0)
+	story.ID, story.By, story.Item.Title, story.loaded = 1, "pg", "story", true
+	story.Kids = []int{2, 3}
+
+	kids := map[int][]int{2: {4, 5}, 3: {7, 8}, 4: {6}}
+	items := map[int]*Item{1: story.Item}
+	for _, tt := range []struct{ id, parent, rank int }{
+		{2, 1, 0}, {3, 1, 1}, {4, 2, 0}, {5, 2, 1}, {6, 4, 0}, {7, 3, 0},
+	} {
+		c := NewComment(tt.rank)
+		c.ID, c.Parent, c.By, c.Text, c.Kids = tt.id, tt.parent, "bob", fmt.Sprintf("comment %d", tt.id), kids[tt.id]
+		items[tt.parent].AddComment(c)
+		items[tt.id] = c.Item
+	}
+
+	p.Story = story
+	p.Render()
+	return p
+}
+
+func key(s string) bbt.KeyMsg {
+	switch s {
+	case "enter":
+		return bbt.KeyMsg{Type: bbt.KeyEnter}
+	}
+
+	return bbt.KeyMsg{Type: bbt.KeyRunes, Runes: []rune(s)}
+}
+
+// shown reports whether the view shows each comment.
+func shown(p *PaneView, ids ...int) map[int]bool {
+	view := p.View()
+	m := make(map[int]bool)
+	for _, id := range ids {
+		m[id] = strings.Contains(view, fmt.Sprintf("comment %d", id))
+	}
+
+	return m
+}
+
+func TestPaneViewCollapse(t *testing.T) {
+	p := newThreadPaneView(t)
+
+	p.Update(key("J"))
+	if p.cursor != 2 {
+		t.Fatalf("cursor = %d, want the first comment", p.cursor)
+	}
+
+	p.Update(key("enter"))
+	if got := shown(p, 2, 4, 5, 6, 3); !got[2] || got[4] || got[5] || got[6] || !got[3] {
+		t.Errorf("shown = %v, want 2 without its replies", got)
+	}
+
+	if view := p.View(); !strings.Contains(view, "[+] 3 replies hidden") {
+		t.Errorf("View() = %q, want 3 replies hidden", view)
+	}
+
+	p.Update(key("enter"))
+	if got := shown(p, 4, 5, 6); !got[4] || !got[5] || !got[6] {
+		t.Errorf("shown = %v, want 2 expanded", got)
+	}
+
+	for p.cursor != 3 {
+		p.Update(key("J"))
+	}
+
+	p.Update(key("enter"))
+
+	// a reply which arrives while its thread is collapsed stays hidden
+	c := NewComment(1)
+	c.ID, c.Parent, c.By, c.Text = 8, 3, "bob", "comment 8"
+	p.Story.find(3).AddComment(c)
+	p.Update(ViewMsg[*Comment]{Value: c})
+
+	if got := shown(p, 3, 7, 8); !got[3] || got[7] || got[8] {
+		t.Errorf("shown = %v, want 3 still collapsed", got)
+	}
+
+	if view := p.View(); !strings.Contains(view, "[+] 2 replies hidden") {
+		t.Errorf("View() = %q, want 2 replies hidden", view)
+	}
+}
+
+func TestPaneViewCollapseAll(t *testing.T) {
+	p := newThreadPaneView(t)
+	for p.cursor != 6 {
+		p.Update(key("J"))
+	}
+
+	p.Update(key("C"))
+	if got := shown(p, 2, 3, 4, 5, 6, 7); !got[2] || !got[3] || got[4] || got[5] || got[6] || got[7] {
+		t.Errorf("shown = %v, want only the top level", got)
+	}
+
+	if p.cursor != 2 {
+		t.Errorf("cursor = %d, want the hidden selection's thread", p.cursor)
+	}
+
+	p.Update(key("E"))
+	if got := shown(p, 2, 3, 4, 5, 6, 7); !got[4] || !got[5] || !got[6] || !got[7] {
+		t.Errorf("shown = %v, want everything", got)
+	}
+}
+
+func TestPaneViewNavigate(t *testing.T) {
+	for _, tt := range []struct {
+		from int
+		key  string
+		want int
+	}{
+		{2, "J", 4},
+		{6, "J", 5},
+		{4, "K", 2},
+		{2, "K", 2},
+		{4, "]", 5},
+		{5, "]", 5},
+		{5, "[", 4},
+		{2, "]", 3},
+		{6, "p", 4},
+		{2, "p", 2},
+		{6, "P", 2},
+		{6, "}", 3},
+		{3, "}", 3},
+		{7, "{", 2},
+	} {
+		t.Run(fmt.Sprintf("%s from %d", tt.key, tt.from), func(t *testing.T) {
+			p := newThreadPaneView(t)
+			p.selectComment(p.Story.find(tt.from))
+
+			p.Update(key(tt.key))
+			if p.cursor != tt.want {
+				t.Errorf("cursor = %d, want %d", p.cursor, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaneViewNavigateScrolls(t *testing.T) {
+	p := newThreadPaneView(t)
+	p.SetSize(80, 8)
+	p.Render()
+
+	for _, k := range []string{"}", "J", "{", "J", "J", "]", "p"} {
+		p.Update(key(k))
+
+		offset, height := p.offsets[p.cursor], p.style.GetHeight()
+		if offset < p.offset || offset >= p.offset+height {
+			t.Errorf("after %s, comment %d starts on line %d, want it in view at [%d, %d)", k, p.cursor, offset, p.offset, p.offset+height)
+		}
+	}
+}
+
+func newTestPaneView(t *testing.T) *PaneView {
+	t.Helper()
+
+	p := NewPaneView(newTestHN(t), &Config{})
+	p.SetSize(80, 24)
+
+	story := NewStory(0)
+	story.ID, story.By, story.Item.Title, story.loaded = 1, "pg", "story", true
+	story.Kids = []int{2, 3}
+	for rank, id := range story.Kids {
+		c := NewComment(rank)
+		c.ID, c.Parent, c.By, c.Text = id, 1, "bob", "comment"
+		story.AddComment(c)
+	}
+
+	p.Story = story
+	p.Render()
+	return p
+}
+
+func TestPaneViewRefreshBeforeRedraw(t *testing.T) {
+	p := newTestPaneView(t)
+
+	newer := NewComment(0)
+	newer.ID, newer.Parent, newer.By, newer.Text = 2, 1, "bob", "edited"
+	p.Update(ViewMsg[*Comment]{Value: newer, refresh: true})
+
+	// the frame which renders the comment again has not arrived yet
+	if view := p.View(); !strings.Contains(view, "comment") {
+		t.Errorf("View() = %q, want the old text until the next frame", view)
+	}
+
+	p.Update(frameMsg{})
+	if view := p.View(); !strings.Contains(view, "edited") {
+		t.Errorf("View() = %q, want the edited text", view)
+	}
+}
+
+func TestPaneViewMissingBlock(t *testing.T) {
+	p := newTestPaneView(t)
+	delete(p.blocks, 2)
+
+	if view := p.View(); !strings.Contains(view, "story") {
+		t.Errorf("View() = %q, want the story", view)
+	}
+}
+
+func newDeadStory() *Story {
+	story := NewStory(0)
+	story.ID, story.By, story.Item.Title, story.loaded = 1, "pg", "secret title", true
+	story.Dead, story.URL = true, "https://example.com/secret"
+	return story
+}
+
+func TestPaneListDeadStory(t *testing.T) {
+	for _, showDead := range []bool{false, true} {
+		p := NewPaneList(newTestHN(t), &Config{ShowDead: showDead})
+		p.SetSize(80, 24)
+		p.model.SetItems([]list.Item{newDeadStory()})
+
+		view := p.View()
+		if got := strings.Contains(view, "secret"); got != showDead {
+			t.Errorf("ShowDead = %t: View() = %q, want title shown %t", showDead, view, showDead)
+		}
+
+		if !strings.Contains(view, "[dead]") {
+			t.Errorf("ShowDead = %t: View() = %q, want [dead]", showDead, view)
+		}
+	}
+}
+
+func TestPaneViewDeadStory(t *testing.T) {
+	for _, showDead := range []bool{false, true} {
+		p := NewPaneView(newTestHN(t), &Config{ShowDead: showDead})
+		p.SetSize(80, 24)
+		p.Story = newDeadStory()
+		p.Render()
+
+		view := p.View()
+		if got := strings.Contains(view, "secret"); got != showDead {
+			t.Errorf("ShowDead = %t: View() = %q, want title and link shown %t", showDead, view, showDead)
+		}
+
+		if !strings.Contains(view, "[dead]") {
+			t.Errorf("ShowDead = %t: View() = %q, want [dead]", showDead, view)
+		}
+	}
+}
+
+func TestPaneViewYankHiddenText(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		hide     func(c *Comment)
+		showDead bool
+		want     string
+	}{
+		{"visible", func(c *Comment) {}, false, "comment"},
+		{"deleted", func(c *Comment) { c.Deleted = true }, false, ""},
+		{"dead", func(c *Comment) { c.Dead = true }, false, ""},
+		{"dead shown", func(c *Comment) { c.Dead = true }, true, "comment"},
+		{"failed", func(c *Comment) { c.err = ErrServer }, false, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPaneView(t)
+			p.config.ShowDead = tt.showDead
+
+			var copied string
+			p.config.Clipboard = NewClipboard(io.Discard, func(text string) error {
+				copied = text
+				return nil
+			})
+			p.config.Clipboard.remote = false
+
+			comment := p.Story.find(2)
+			tt.hide(comment)
+			p.selectComment(comment)
+
+			msg := p.chordCmd("y t")()
+			if copied != tt.want {
+				t.Errorf("copied %q, want %q", copied, tt.want)
+			}
+
+			if _, ok := msg.(ErrorMsg); ok != (tt.want == "") {
+				t.Errorf("msg = %v, want an error only if nothing is copied", msg)
+			}
+		})
+	}
+}
+
+func TestPaneViewYankLinks(t *testing.T) {
+	p := newTestPaneView(t)
+
+	var copied string
+	p.config.Clipboard = NewClipboard(io.Discard, func(text string) error {
+		copied = text
+		return nil
+	})
+	p.config.Clipboard.remote = false
+
+	comment := p.Story.find(2)
+	comment.Text = `see <a href="https://example.com/x">here</a> for more`
+	p.selectComment(comment)
+
+	p.chordCmd("y t")()
+	if want := "see here (https://example.com/x) for more"; copied != want {
+		t.Errorf("copied %q, want %q", copied, want)
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/muesli/reflow/wrap"
+	"golang.org/x/net/html"
+)
+
+// ErrNoArticle is returned for pages without any readable text.
+var ErrNoArticle = errors.New("no readable text")
+
+// maxArticle is the most of a page which is read, in bytes.
+const maxArticle = 5 << 20
+
+// Article is the readable content of a web page.
+type Article struct {
+	URL   *url.URL
+	Title string
+
+	// Content holds the elements judged to be the article's text, in
+	// document order.
+	Content []*html.Node
+}
+
+// Article downloads the page at link and extracts its main content. Pages
+// are neither cached nor retried.
+func (h *HN) Article(ctx context.Context, link string) (*Article, error) {
+	if h.Offline() {
+		return nil, ErrUnavailable
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%s: unsupported link", link)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.userAgent != "" {
+		request.Header.Set("User-Agent", h.userAgent)
+	}
+
+	request.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: link, StatusCode: response.StatusCode}
+	}
+
+	if contentType := response.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			return nil, fmt.Errorf("%s: not a web page (%s)", link, mediaType)
+		}
+	}
+
+	// links are relative to wherever redirects ended up
+	return ParseArticle(io.LimitReader(response.Body, maxArticle), response.Request.URL)
+}
+
+// ParseArticle extracts the main content of a page from base, scoring
+// elements by the paragraphs they hold like Readability.
+func ParseArticle(r io.Reader, base *url.URL) (*Article, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	article := Article{URL: base, Title: title(root)}
+
+	body := find(root, "body")
+	if body == nil {
+		body = root
+	}
+
+	prune(body)
+	article.Content = content(body)
+	if len(article.Content) == 0 {
+		return nil, ErrNoArticle
+	}
+
+	return &article, nil
+}
+
+// Render renders the article wrapped at width, followed by the targets of
+// its links. Links are hyperlinks if links is enabled.
+func (a *Article) Render(width int, links *Hyperlinks) string {
+	r := newHTMLRenderer(a.URL, true, links)
+
+	blocks := r.nodes(a.Content, width)
+	if len(r.footnoted) > 0 {
+		lines := []string{r.styleHeading.Render("Links")}
+		for i, link := range r.footnoted {
+			// links are too long to wrap at word boundaries
+			lines = append(lines, links.Link(link, r.styleFaint.Render(wrap.String(fmt.Sprintf("[%d] %s", i+1, link), width))))
+		}
+
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// title finds the page's title in its metadata, or its first heading.
+func title(root *html.Node) string {
+	var og, head, h1 string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "meta":
+				if attr(n, "property") == "og:title" && og == "" {
+					og = attr(n, "content")
+				}
+			case "title":
+				if head == "" {
+					head = textContent(n)
+				}
+			case "h1":
+				if h1 == "" {
+					h1 = textContent(n)
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(root)
+	for _, s := range []string{og, head, h1} {
+		if s = strings.Join(strings.Fields(s), " "); s != "" {
+			return s
+		}
+	}
+
+	return ""
+}
+
+// find returns the first element named tag below n.
+func find(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, tag); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+var (
+	// unlikelyCandidates matches the class or id of page furniture around
+	// an article, e.g. menus and share buttons
+	unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|footer|header|menu|modal|newsletter|pagination|popup|promo|related|remark|rss|share|shoutbox|sidebar|social|sponsor|subscribe|tags|tool|widget|\bads?\b`)
+
+	// maybeCandidate rescues elements which match both, e.g. main-header
+	maybeCandidate = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+
+	positiveWeight = regexp.MustCompile(`(?i)article|body|content|entry|hentry|main|page|post|text|blog|story`)
+	negativeWeight = regexp.MustCompile(`(?i)hidden|combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// prune removes elements which are never part of the article.
+func prune(n *html.Node) {
+	var remove []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.CommentNode {
+			remove = append(remove, c)
+			continue
+		} else if c.Type != html.ElementNode {
+			continue
+		}
+
+		switch c.Data {
+		case "aside", "footer":
+			remove = append(remove, c)
+			continue
+		case "article", "main":
+			// never mistake the article itself for furniture
+		default:
+			if skippedElements[c.Data] {
+				remove = append(remove, c)
+				continue
+			}
+
+			if match := attr(c, "class") + " " + attr(c, "id"); unlikelyCandidates.MatchString(match) && !maybeCandidate.MatchString(match) {
+				remove = append(remove, c)
+				continue
+			}
+		}
+
+		prune(c)
+	}
+
+	for _, c := range remove {
+		n.RemoveChild(c)
+	}
+}
+
+// content picks the element with the highest scoring paragraphs, along
+// with any siblings which score nearly as well.
+func content(body *html.Node) []*html.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+
+	add := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+
+		scores[n] += score
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "p", "pre", "td":
+				text := strings.TrimSpace(textContent(n))
+				if len(text) < 25 {
+					break
+				}
+
+				// more text and more clauses score higher
+				score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+				add(n.Parent, score)
+				if n.Parent != nil {
+					add(n.Parent.Parent, score/2)
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(body)
+
+	var top *html.Node
+	for _, n := range candidates {
+		// text which is mostly links is a list of links, not an article
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+
+	if top == nil {
+		if strings.TrimSpace(textContent(body)) == "" {
+			return nil
+		}
+
+		return []*html.Node{body}
+	} else if top.Parent == nil {
+		return []*html.Node{top}
+	}
+
+	// articles are often split among siblings, e.g. by ads
+	threshold := math.Max(10, scores[top]*0.2)
+
+	var nodes []*html.Node
+	for c := top.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if score, ok := scores[c]; c == top || (ok && score >= threshold) {
+			nodes = append(nodes, c)
+		} else if c.Type == html.ElementNode && c.Data == "p" {
+			if text := strings.TrimSpace(textContent(c)); len(text) > 80 && linkDensity(c) < 0.25 {
+				nodes = append(nodes, c)
+			}
+		}
+	}
+
+	return nodes
+}
+
+// initialScore weighs an element by its tag, class and id before counting
+// its paragraphs.
+func initialScore(n *html.Node) float64 {
+	var score float64
+	switch n.Data {
+	case "article", "main":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	for _, s := range []string{attr(n, "class"), attr(n, "id")} {
+		if s == "" {
+			continue
+		}
+
+		if negativeWeight.MatchString(s) {
+			score -= 25
+		}
+
+		if positiveWeight.MatchString(s) {
+			score += 25
+		}
+	}
+
+	return score
+}
+
+// linkDensity is the share of the text below n inside links.
+func linkDensity(n *html.Node) float64 {
+	text := len(strings.TrimSpace(textContent(n)))
+	if text == 0 {
+		return 0
+	}
+
+	var links int
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			links += len(strings.TrimSpace(textContent(n)))
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(n)
+	return float64(links) / float64(text)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func parseFixture(t *testing.T, name string) (*Article, error) {
+	t.Helper()
+
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	base, err := url.Parse("https://blog.example.com/posts/reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ParseArticle(f, base)
+}
+
+func TestParseArticle(t *testing.T) {
+	article, err := parseFixture(t, "article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Writing a Terminal Reader"; article.Title != want {
+		t.Errorf("Title = %q, want %q", article.Title, want)
+	}
+
+	var sb strings.Builder
+	for _, n := range article.Content {
+		sb.WriteString(textContent(n))
+	}
+
+	text := sb.String()
+	for _, want := range []string{"Terminals are a fine place", "Bullets for unordered lists", "keeps its indentation", "the guide"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("content is missing %q", want)
+		}
+	}
+
+	for _, boilerplate := range []string{"navigation text", "script text", "Sidebar text", "Share this", "A comment below", "Copyright footer"} {
+		if strings.Contains(text, boilerplate) {
+			t.Errorf("content includes %q", boilerplate)
+		}
+	}
+}
+
+func TestParseArticleEmpty(t *testing.T) {
+	if _, err := parseFixture(t, "empty.html"); !errors.Is(err, ErrNoArticle) {
+		t.Errorf("err = %v, want %v", err, ErrNoArticle)
+	}
+}
+
+func TestArticleRender(t *testing.T) {
+	article, err := parseFixture(t, "article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const width = 60
+	rendered := article.Render(width, nil)
+	for _, want := range []string{
+		// headings
+		"\nLists\n",
+		"\nCode\n",
+		// lists, nested and numbered from start
+		"• Bullets for unordered lists",
+		"\n  • are indented below their item",
+		"3. numbers start where the list says",
+		"4. and count up from there",
+		// pre blocks keep their whitespace
+		"    fmt.Println(\"keeps its indentation\")",
+		// links are numbered and listed, resolved against the page
+		"the guide[1]",
+		"the spec[2]",
+		"[1] https://blog.example.com/docs/guide",
+		"[2] https://example.org/spec",
+		// > is only a quote on HN
+		"> npm install",
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Render() is missing %q:\n%s", want, rendered)
+		}
+	}
+
+	for _, line := range strings.Split(rendered, "\n") {
+		if w := lipgloss.Width(line); w > width {
+			t.Errorf("line %q is %d wide, want at most %d", line, w, width)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how transient failures, i.e. timeouts, dropped or
+// refused connections, rate limiting and server errors, are retried.
+type RetryPolicy struct {
+	// Retries is the number of attempts after the first.
+	Retries int
+
+	// Base is the delay before the first retry. It doubles with every
+	// retry, up to Max.
+	Base time.Duration
+	Max  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Retries: 3,
+	Base:    250 * time.Millisecond,
+	Max:     10 * time.Second,
+}
+
+// Backoff returns the delay before retry attempt, counting from zero. The
+// delay is randomized between zero and the exponential bound so clients
+// retrying together spread out.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	bound := p.Base << attempt
+	if bound <= 0 || bound > p.Max {
+		bound = p.Max
+	}
+
+	if bound <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(bound)))
+}
+
+// transient reports whether err is worth retrying. Other network errors,
+// e.g. bad certificates or unknown hosts, fail the same way every time.
+func transient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServer) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	// every error from http.Client is a net.Error, so only its timeouts
+	// count
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
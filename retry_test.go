@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{Base: 100 * time.Millisecond, Max: time.Second}
+	for attempt, bound := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for i := 0; i < 100; i++ {
+			if d := p.Backoff(attempt); d < 0 || d >= bound {
+				t.Fatalf("Backoff(%d) = %s, want [0, %s)", attempt, d, bound)
+			}
+		}
+	}
+
+	// shifting far enough overflows, which must not escape Max
+	if d := p.Backoff(80); d < 0 || d >= p.Max {
+		t.Errorf("Backoff(80) = %s, want [0, %s)", d, p.Max)
+	}
+
+	if d := (RetryPolicy{}).Backoff(0); d != 0 {
+		t.Errorf("Backoff(0) without delays = %s, want 0", d)
+	}
+}
+
+// timeoutError is a net.Error which timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestTransient(t *testing.T) {
+	get := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://hacker-news.firebaseio.com/v0/item/1.json", Err: err}
+	}
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &StatusError{StatusCode: 429}, true},
+		{"server error", &StatusError{StatusCode: 503}, true},
+		{"not found", &StatusError{StatusCode: 404}, false},
+		{"truncated body", fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), true},
+		{"timeout", get(timeoutError{}), true},
+		{"connection reset", get(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"connection refused", get(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), true},
+		{"unknown host", get(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}), false},
+		{"bad certificate", get(x509.UnknownAuthorityError{}), false},
+		{"bad scheme", get(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"invalid json", errors.New("invalid character"), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transient(context.Background(), tt.err); got != tt.want {
+				t.Errorf("transient(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if transient(ctx, ErrServer) {
+		t.Error("transient after the context is done = true, want false")
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned for fetches which were queued or requested after
+// the scheduler closed.
+var ErrClosed = errors.New("scheduler closed")
+
+// Priority orders queued fetches. Higher priorities are fetched first.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx whose fetches are scheduled at p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+
+	return PriorityNormal
+}
+
+// Progress is a snapshot of the scheduler's activity.
+type Progress struct {
+	Queued int
+	Active int
+	Done   int
+	Failed int
+}
+
+// Pending is the number of fetches which have not completed yet.
+func (p Progress) Pending() int {
+	return p.Queued + p.Active
+}
+
+type fetch struct {
+	key      string
+	priority Priority
+	seq      int
+	index    int
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+type fetchQueue []*fetch
+
+func (q fetchQueue) Len() int { return len(q) }
+
+func (q fetchQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q fetchQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *fetchQueue) Push(x any) {
+	f := x.(*fetch)
+	f.index = len(*q)
+	*q = append(*q, f)
+}
+
+func (q *fetchQueue) Pop() any {
+	old := *q
+	f := old[len(old)-1]
+	old[len(old)-1] = nil
+	f.index = -1
+	*q = old[:len(old)-1]
+	return f
+}
+
+// Scheduler runs fetches on a fixed pool of workers. Queued fetches are
+// ordered by priority and concurrent requests for the same key share a
+// single fetch.
+type Scheduler struct {
+	fn func(context.Context, string) ([]byte, error)
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	seq      int
+	queue    fetchQueue
+	inflight map[string]*fetch
+	progress Progress
+	closed   bool
+
+	workers sync.WaitGroup
+}
+
+func NewScheduler(workers int, fn func(context.Context, string) ([]byte, error)) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+
+	s := Scheduler{
+		fn:       fn,
+		inflight: make(map[string]*fetch),
+	}
+
+	s.cond = sync.NewCond(&s.mu)
+	s.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go s.work()
+	}
+
+	return &s
+}
+
+func (s *Scheduler) work() {
+	defer s.workers.Done()
+
+	for {
+		s.mu.Lock()
+		for s.queue.Len() == 0 && !s.closed {
+			s.cond.Wait()
+		}
+
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+
+		f := heap.Pop(&s.queue).(*fetch)
+		s.progress.Queued--
+		s.progress.Active++
+		s.mu.Unlock()
+
+		value, err := s.fn(f.ctx, f.key)
+
+		s.mu.Lock()
+		f.value, f.err = value, err
+		if s.inflight[f.key] == f {
+			delete(s.inflight, f.key)
+		}
+
+		s.progress.Active--
+		switch {
+		case f.ctx.Err() != nil:
+			// abandoned by every waiter
+		case err != nil:
+			s.progress.Failed++
+		default:
+			s.progress.Done++
+		}
+		s.mu.Unlock()
+
+		f.cancel()
+		close(f.done)
+	}
+}
+
+// Do fetches key at the priority carried by ctx, joining an identical fetch
+// if one is already queued or running. The underlying fetch is only
+// cancelled once every caller waiting on it has given up.
+func (s *Scheduler) Do(ctx context.Context, key string) ([]byte, error) {
+	priority := priorityFrom(ctx)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, ErrClosed
+	}
+
+	f, ok := s.inflight[key]
+	if !ok {
+		fctx, cancel := context.WithCancel(context.Background())
+		f = &fetch{
+			key:      key,
+			priority: priority,
+			seq:      s.seq,
+			ctx:      fctx,
+			cancel:   cancel,
+			done:     make(chan struct{}),
+		}
+
+		s.seq++
+		s.inflight[key] = f
+		heap.Push(&s.queue, f)
+		s.progress.Queued++
+		s.cond.Signal()
+	} else if priority > f.priority && f.index >= 0 {
+		f.priority = priority
+		heap.Fix(&s.queue, f.index)
+	}
+
+	f.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		f.waiters--
+		if f.waiters == 0 {
+			if f.index >= 0 {
+				heap.Remove(&s.queue, f.index)
+				s.progress.Queued--
+			}
+
+			if s.inflight[key] == f {
+				delete(s.inflight, key)
+			}
+
+			f.cancel()
+		}
+
+		return nil, ctx.Err()
+	}
+}
+
+// Promote raises the priority of key if it is still queued.
+func (s *Scheduler) Promote(key string, priority Priority) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.inflight[key]; ok && f.index >= 0 && priority > f.priority {
+		f.priority = priority
+		heap.Fix(&s.queue, f.index)
+	}
+}
+
+// Close stops the workers, cancelling running fetches and failing queued
+// ones with ErrClosed, and waits for the workers to return.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+
+	s.closed = true
+	for s.queue.Len() > 0 {
+		f := heap.Pop(&s.queue).(*fetch)
+		s.progress.Queued--
+		f.err = ErrClosed
+		f.cancel()
+		close(f.done)
+	}
+
+	for key, f := range s.inflight {
+		// only running fetches are left
+		f.cancel()
+		delete(s.inflight, key)
+	}
+
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	s.workers.Wait()
+}
+
+func (s *Scheduler) Progress() Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progress
+}
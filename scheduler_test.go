@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls until cond holds, failing the test if it never does.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerCoalesces(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	s := NewScheduler(4, func(ctx context.Context, key string) ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte(key), nil
+	})
+
+	const callers = 5
+	var wg sync.WaitGroup
+	values := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := s.Do(context.Background(), "/item/1.json")
+			if err != nil {
+				t.Error(err)
+			}
+
+			values[i] = string(value)
+		}(i)
+	}
+
+	waitFor(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		f, ok := s.inflight["/item/1.json"]
+		return ok && f.waiters == callers
+	})
+
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+
+	for i, value := range values {
+		if value != "/item/1.json" {
+			t.Errorf("caller %d got %q", i, value)
+		}
+	}
+
+	if p := s.Progress(); p.Done != 1 || p.Pending() != 0 {
+		t.Errorf("Progress() = %+v, want 1 done and none pending", p)
+	}
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	s := NewScheduler(1, func(ctx context.Context, key string) ([]byte, error) {
+		if key == "busy" {
+			<-release
+		}
+
+		mu.Lock()
+		order = append(order, key)
+		mu.Unlock()
+		return nil, nil
+	})
+
+	var wg sync.WaitGroup
+	do := func(key string, priority Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Do(WithPriority(context.Background(), priority), key); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// occupy the only worker so the rest queue up
+	do("busy", PriorityNormal)
+	waitFor(t, func() bool { return s.Progress().Active == 1 })
+
+	// queue one at a time, so fetches of the same priority keep this order
+	for i, f := range []struct {
+		key      string
+		priority Priority
+	}{
+		{"low", PriorityLow},
+		{"normal", PriorityNormal},
+		{"high", PriorityHigh},
+		{"promoted", PriorityLow},
+	} {
+		do(f.key, f.priority)
+		waitFor(t, func() bool { return s.Progress().Queued == i+1 })
+	}
+
+	s.Promote("promoted", PriorityHigh)
+
+	close(release)
+	wg.Wait()
+
+	want := []string{"busy", "high", "promoted", "normal", "low"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestSchedulerCancelQueued(t *testing.T) {
+	release := make(chan struct{})
+	s := NewScheduler(1, func(ctx context.Context, key string) ([]byte, error) {
+		if key == "busy" {
+			<-release
+		} else {
+			t.Errorf("fetched %q after every caller gave up", key)
+		}
+
+		return nil, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Do(context.Background(), "busy")
+	}()
+
+	waitFor(t, func() bool { return s.Progress().Active == 1 })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := s.Do(ctx, "abandoned")
+		errs <- err
+	}()
+
+	waitFor(t, func() bool { return s.Progress().Queued == 1 })
+	cancel()
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+
+	if p := s.Progress(); p.Queued != 0 {
+		t.Errorf("Progress() = %+v, want nothing queued", p)
+	}
+
+	close(release)
+	<-done
+}
+
+func TestSchedulerClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	started := make(chan struct{})
+	s := NewScheduler(2, func(ctx context.Context, key string) ([]byte, error) {
+		if key == "queued" {
+			t.Error("fetched a queued key after closing")
+		}
+
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	errs := make(chan error, 3)
+	for _, key := range []string{"running", "also running", "queued"} {
+		go func(key string) {
+			_, err := s.Do(context.Background(), key)
+			errs <- err
+		}(key)
+
+		if key != "queued" {
+			<-started
+		}
+	}
+
+	waitFor(t, func() bool { return s.Progress().Queued == 1 })
+	s.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err == nil {
+			t.Error("a fetch succeeded after closing")
+		}
+	}
+
+	if _, err := s.Do(context.Background(), "late"); !errors.Is(err, ErrClosed) {
+		t.Errorf("err = %v, want %v", err, ErrClosed)
+	}
+
+	// every worker has returned
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= before })
+}
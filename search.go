@@ -0,0 +1,229 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchQuery filters a search of the Algolia Hacker News index.
+//
+// ref: https://hn.algolia.com/api
+type SearchQuery struct {
+	Text string
+
+	// Tags restricts results to any of story, comment, ask_hn, show_hn,
+	// poll or front_page.
+	Tags []string
+
+	Author string
+
+	// After and Before bound the creation time, if set.
+	After, Before time.Time
+
+	// MinPoints excludes results with fewer points.
+	MinPoints int
+
+	// ByDate sorts newest first instead of by relevance and points.
+	ByDate bool
+
+	Page        int
+	HitsPerPage int
+}
+
+// ParseSearchQuery reads a query typed into the search window. Words of
+// the form key:value set filters; everything else is the search text.
+//
+//	rust author:pg tag:show_hn points>100 after:2023-01-01 before:2023-02-01 sort:date
+func ParseSearchQuery(s string) (SearchQuery, error) {
+	var q SearchQuery
+	var text []string
+	for _, word := range strings.Fields(s) {
+		if rest, ok := strings.CutPrefix(word, "points>="); ok {
+			n, err := strconv.Atoi(rest)
+			if err != nil {
+				return q, fmt.Errorf("invalid points: %q", rest)
+			}
+
+			q.MinPoints = n
+			continue
+		} else if rest, ok := strings.CutPrefix(word, "points>"); ok {
+			n, err := strconv.Atoi(rest)
+			if err != nil {
+				return q, fmt.Errorf("invalid points: %q", rest)
+			}
+
+			q.MinPoints = n + 1
+			continue
+		}
+
+		key, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			text = append(text, word)
+			continue
+		}
+
+		switch key {
+		case "author", "by":
+			q.Author = value
+		case "tag", "tags":
+			q.Tags = append(q.Tags, strings.Split(value, ",")...)
+		case "after", "before":
+			t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s date: %q", key, value)
+			}
+
+			if key == "after" {
+				q.After = t
+			} else {
+				q.Before = t
+			}
+		case "sort":
+			q.ByDate = value == "date"
+		default:
+			text = append(text, word)
+		}
+	}
+
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// SearchHit is a single search result. Points and NumComments are only set
+// for stories; StoryID and ParentID only for comments.
+type SearchHit struct {
+	ObjectID    string `json:"objectID"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Author      string `json:"author"`
+	Points      int    `json:"points"`
+	NumComments int    `json:"num_comments"`
+	StoryID     int    `json:"story_id"`
+	ParentID    int    `json:"parent_id"`
+	StoryTitle  string `json:"story_title"`
+	CommentText string `json:"comment_text"`
+	CreatedAt   int64  `json:"created_at_i"`
+}
+
+type SearchResult struct {
+	Hits        []SearchHit `json:"hits"`
+	Total       int         `json:"nbHits"`
+	Page        int         `json:"page"`
+	Pages       int         `json:"nbPages"`
+	HitsPerPage int         `json:"hitsPerPage"`
+}
+
+// IDs returns the item IDs of the hits, in order.
+func (r *SearchResult) IDs() []int {
+	ids := make([]int, 0, len(r.Hits))
+	for _, hit := range r.Hits {
+		if id, err := strconv.Atoi(hit.ObjectID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// Search queries the search API. Results are never cached.
+func (h *HN) Search(ctx context.Context, q SearchQuery) (*SearchResult, error) {
+	if h.Offline() {
+		return nil, ErrUnavailable
+	}
+
+	endpoint := "search"
+	if q.ByDate {
+		endpoint = "search_by_date"
+	}
+
+	var tags []string
+	switch len(q.Tags) {
+	case 0:
+	case 1:
+		tags = append(tags, q.Tags[0])
+	default:
+		// parentheses match any of the tags rather than all of them
+		tags = append(tags, fmt.Sprintf("(%s)", strings.Join(q.Tags, ",")))
+	}
+
+	if q.Author != "" {
+		tags = append(tags, "author_"+q.Author)
+	}
+
+	var filters []string
+	if !q.After.IsZero() {
+		filters = append(filters, fmt.Sprintf("created_at_i>=%d", q.After.Unix()))
+	}
+
+	if !q.Before.IsZero() {
+		filters = append(filters, fmt.Sprintf("created_at_i<%d", q.Before.Unix()))
+	}
+
+	if q.MinPoints > 0 {
+		filters = append(filters, fmt.Sprintf("points>=%d", q.MinPoints))
+	}
+
+	requestURL := h.searchURL.JoinPath(endpoint)
+	values := requestURL.Query()
+	values.Set("query", q.Text)
+	if len(tags) > 0 {
+		values.Set("tags", strings.Join(tags, ","))
+	}
+
+	if len(filters) > 0 {
+		values.Set("numericFilters", strings.Join(filters, ","))
+	}
+
+	if q.Page > 0 {
+		values.Set("page", strconv.Itoa(q.Page))
+	}
+
+	if q.HitsPerPage > 0 {
+		values.Set("hitsPerPage", strconv.Itoa(q.HitsPerPage))
+	}
+
+	requestURL.RawQuery = values.Encode()
+
+	body, err := h.fetchURL(ctx, requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SearchResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &DecodeError{URL: requestURL.String(), Err: err}
+	}
+
+	return &result, nil
+}
+
+// frontHits is the number of stories loaded for a past front page.
+const frontHits = 100
+
+// Front returns the stories submitted on the date of day, from midnight to
+// midnight UTC whatever the location of day, with the most points first,
+// like https://news.ycombinator.com/front?day=
+func (h *HN) Front(ctx context.Context, day time.Time) ([]int, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	result, err := h.Search(ctx, SearchQuery{
+		Tags:        []string{"story"},
+		After:       start,
+		Before:      start.AddDate(0, 0, 1),
+		HitsPerPage: frontHits,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(result.Hits, func(a, b SearchHit) int {
+		return cmp.Compare(b.Points, a.Points)
+	})
+
+	return result.IDs(), nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return d
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  SearchQuery
+	}{
+		{"", SearchQuery{}},
+		{"rust async", SearchQuery{Text: "rust async"}},
+		{"rust author:pg", SearchQuery{Text: "rust", Author: "pg"}},
+		{"by:pg", SearchQuery{Author: "pg"}},
+		{"tag:show_hn tags:ask_hn,poll", SearchQuery{Tags: []string{"show_hn", "ask_hn", "poll"}}},
+		{"points>100", SearchQuery{MinPoints: 101}},
+		{"points>=100", SearchQuery{MinPoints: 100}},
+		{"after:2023-01-01 before:2023-02-01", SearchQuery{After: date("2023-01-01"), Before: date("2023-02-01")}},
+		{"sort:date go", SearchQuery{Text: "go", ByDate: true}},
+		{"sort:points", SearchQuery{}},
+		{"http://example.com author:", SearchQuery{Text: "http://example.com author:"}},
+		{"key:value", SearchQuery{Text: "key:value"}},
+	} {
+		got, err := ParseSearchQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseSearchQuery(%q): %v", tt.query, err)
+			continue
+		}
+
+		if got.Text != tt.want.Text || got.Author != tt.want.Author || !slices.Equal(got.Tags, tt.want.Tags) ||
+			!got.After.Equal(tt.want.After) || !got.Before.Equal(tt.want.Before) ||
+			got.MinPoints != tt.want.MinPoints || got.ByDate != tt.want.ByDate {
+			t.Errorf("ParseSearchQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseSearchQueryInvalid(t *testing.T) {
+	for _, query := range []string{
+		"after:yesterday",
+		"before:2023-13-01",
+		"after:2023-1-1",
+		"points>many",
+		"points>=",
+		"points>1.5",
+	} {
+		if q, err := ParseSearchQuery(query); err == nil {
+			t.Errorf("ParseSearchQuery(%q) = %+v, want an error", query, q)
+		}
+	}
+}
+
+// newSearchServer serves body for every search, recording each request.
+func newSearchServer(t *testing.T, body string) (*HN, *[]*http.Request) {
+	t.Helper()
+
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	searchURL, err := url.Parse(server.URL + "/api/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newTestHN(t, WithSearchURL(searchURL)), &requests
+}
+
+func TestSearch(t *testing.T) {
+	hn, requests := newSearchServer(t, `{
+		"hits": [
+			{"objectID": "1", "title": "story", "url": "https://example.com", "author": "pg", "points": 10, "num_comments": 2, "created_at_i": 1700000000},
+			{"objectID": "2", "author": "bob", "story_id": 1, "parent_id": 1, "story_title": "story", "comment_text": "comment"},
+			{"objectID": "not a number"}
+		],
+		"nbHits": 3, "page": 1, "nbPages": 2, "hitsPerPage": 3
+	}`)
+
+	after := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	result, err := hn.Search(context.Background(), SearchQuery{
+		Text:        "rust async",
+		Tags:        []string{"story", "show_hn"},
+		Author:      "pg",
+		After:       after,
+		Before:      before,
+		MinPoints:   100,
+		Page:        1,
+		HitsPerPage: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := (*requests)[0]
+	if r.URL.Path != "/api/v1/search" {
+		t.Errorf("path = %q, want /api/v1/search", r.URL.Path)
+	}
+
+	query := r.URL.Query()
+	for key, want := range map[string]string{
+		"query":          "rust async",
+		"tags":           "(story,show_hn),author_pg",
+		"numericFilters": fmt.Sprintf("created_at_i>=%d,created_at_i<%d,points>=100", after.Unix(), before.Unix()),
+		"page":           "1",
+		"hitsPerPage":    "3",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	if result.Total != 3 || result.Page != 1 || result.Pages != 2 || result.HitsPerPage != 3 {
+		t.Errorf("result = %+v, want page 1 of 2 with 3 hits", result)
+	}
+
+	if hit := result.Hits[0]; hit.Title != "story" || hit.URL != "https://example.com" || hit.Author != "pg" || hit.Points != 10 || hit.NumComments != 2 || hit.CreatedAt != 1700000000 {
+		t.Errorf("story hit = %+v", hit)
+	}
+
+	if hit := result.Hits[1]; hit.StoryID != 1 || hit.ParentID != 1 || hit.StoryTitle != "story" || hit.CommentText != "comment" {
+		t.Errorf("comment hit = %+v", hit)
+	}
+
+	// hits without a numeric ID are left out
+	if ids := result.IDs(); !slices.Equal(ids, []int{1, 2}) {
+		t.Errorf("IDs() = %v, want [1 2]", ids)
+	}
+}
+
+func TestSearchByDate(t *testing.T) {
+	hn, requests := newSearchServer(t, `{"hits": []}`)
+	if _, err := hn.Search(context.Background(), SearchQuery{Text: "go", Tags: []string{"comment"}, ByDate: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := (*requests)[0]
+	if r.URL.Path != "/api/v1/search_by_date" {
+		t.Errorf("path = %q, want /api/v1/search_by_date", r.URL.Path)
+	}
+
+	query := r.URL.Query()
+	if query.Get("tags") != "comment" || query.Has("numericFilters") || query.Has("page") {
+		t.Errorf("query = %v, want only the query and a tag", query)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	hn, _ := newSearchServer(t, `not json`)
+
+	var decodeErr *DecodeError
+	if _, err := hn.Search(context.Background(), SearchQuery{Text: "go"}); !errors.As(err, &decodeErr) {
+		t.Errorf("err = %v, want a DecodeError", err)
+	}
+
+	offline := newTestHN(t, WithCache(NewCache(t.TempDir())), WithOffline())
+	if _, err := offline.Search(context.Background(), SearchQuery{Text: "go"}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want %v", err, ErrUnavailable)
+	}
+}
+
+func TestFront(t *testing.T) {
+	hn, requests := newSearchServer(t, `{"hits": [
+		{"objectID": "1", "points": 5},
+		{"objectID": "2", "points": 300},
+		{"objectID": "3", "points": 40},
+		{"objectID": "4", "points": 300}
+	]}`)
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, day := range []time.Time{
+		start,
+		time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC),
+		// late on the 1st west of UTC is already the 2nd in UTC, but the
+		// date is what counts
+		time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("PST", -8*60*60)),
+		time.Date(2024, 3, 1, 0, 30, 0, 0, time.FixedZone("JST", 9*60*60)),
+	} {
+		*requests = nil
+		ids, err := hn.Front(context.Background(), day)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// ties keep the order of the search
+		if want := []int{2, 4, 3, 1}; !slices.Equal(ids, want) {
+			t.Errorf("Front(%v) = %v, want %v", day, ids, want)
+		}
+
+		query := (*requests)[0].URL.Query()
+		if want := fmt.Sprintf("created_at_i>=%d,created_at_i<%d", start.Unix(), start.AddDate(0, 0, 1).Unix()); query.Get("numericFilters") != want {
+			t.Errorf("Front(%v) filters = %q, want %q", day, query.Get("numericFilters"), want)
+		}
+
+		if query.Get("tags") != "story" || query.Get("hitsPerPage") != fmt.Sprint(frontHits) {
+			t.Errorf("Front(%v) query = %v, want %d stories", day, query, frontHits)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a server-sent event from the Firebase streaming API. Data is
+// the new value at Path, relative to the streamed location.
+//
+// ref: https://firebase.google.com/docs/reference/rest/database#section-streaming
+type Event struct {
+	Type string
+	Path string
+	Data json.RawMessage
+}
+
+// Stream subscribes to path and sends its events until ctx is done or the
+// server closes the stream. The first event is a put of the whole value.
+func (h *HN) Stream(ctx context.Context, path string) (<-chan Event, error) {
+	if h.Offline() {
+		return nil, ErrUnavailable
+	}
+
+	requestURL := h.baseURL.JoinPath(path)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", "text/event-stream")
+	if h.userAgent != "" {
+		request.Header.Set("User-Agent", h.userAgent)
+	}
+
+	// streams stay open indefinitely so the request timeout does not apply
+	client := *h.client
+	client.Timeout = 0
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, &StatusError{URL: requestURL.String(), StatusCode: response.StatusCode}
+	}
+
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		defer response.Body.Close()
+
+		_ = readEvents(response.Body, func(event Event) bool {
+			select {
+			case ch <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return ch, nil
+}
+
+// readEvents parses server-sent events from r and calls fn for each until
+// it returns false. Streams end with a cancel or auth_revoked event.
+func readEvents(r io.Reader, fn func(Event) bool) error {
+	var name string
+	var data []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if name == "" && len(data) == 0 {
+				continue
+			}
+
+			event := Event{Type: name}
+			body := strings.Join(data, "\n")
+			name, data = "", nil
+
+			switch event.Type {
+			case "put", "patch":
+				var payload struct {
+					Path string          `json:"path"`
+					Data json.RawMessage `json:"data"`
+				}
+
+				if err := json.Unmarshal([]byte(body), &payload); err != nil {
+					return &DecodeError{URL: event.Type, Err: err}
+				}
+
+				event.Path, event.Data = payload.Path, payload.Data
+			case "cancel", "auth_revoked":
+				return fmt.Errorf("stream %s: %s", event.Type, body)
+			default:
+				// keep-alive
+				continue
+			}
+
+			if !fn(event) {
+				return nil
+			}
+
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return io.ErrUnexpectedEOF
+}
+
+// StreamIDs follows a list of IDs at path, e.g. a story list or an item's
+// kids, and sends the whole list every time it changes. Dropped streams
+// are reconnected with backoff until ctx is done.
+func (h *HN) StreamIDs(ctx context.Context, path string) (<-chan []int, error) {
+	events, err := h.Stream(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []int)
+	go func() {
+		defer close(ch)
+
+		ids := make(map[int]int)
+		for attempt := 0; ; {
+			for event := range events {
+				if err := applyIDs(ids, event); err != nil {
+					continue
+				}
+
+				attempt = 0
+				if h.cache != nil {
+					if body, err := json.Marshal(snapshot(ids)); err == nil {
+						_ = h.cache.Put(path, body)
+					}
+				}
+
+				select {
+				case ch <- snapshot(ids):
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			for {
+				select {
+				case <-time.After(h.retry.Backoff(attempt)):
+				case <-ctx.Done():
+					return
+				}
+
+				attempt++
+				if events, err = h.Stream(ctx, path); err == nil {
+					break
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// StreamStories follows a story list, e.g. "top".
+func (h *HN) StreamStories(ctx context.Context, kind string) (<-chan []int, error) {
+	return h.StreamIDs(ctx, fmt.Sprintf("/%sstories.json", kind))
+}
+
+// StreamKids follows the kids of item id.
+func (h *HN) StreamKids(ctx context.Context, id int) (<-chan []int, error) {
+	return h.StreamIDs(ctx, fmt.Sprintf("/item/%d/kids.json", id))
+}
+
+// applyIDs applies a put or patch event to ids, a list keyed by index.
+// Firebase sends lists as either arrays or objects keyed by index.
+func applyIDs(ids map[int]int, event Event) error {
+	index := strings.Trim(event.Path, "/")
+	switch {
+	case event.Type == "put" && index == "":
+		for n := range ids {
+			delete(ids, n)
+		}
+
+		return mergeIDs(ids, event.Data)
+	case event.Type == "put":
+		n, err := strconv.Atoi(index)
+		if err != nil {
+			return err
+		}
+
+		var id *int
+		if err := json.Unmarshal(event.Data, &id); err != nil {
+			return err
+		} else if id == nil {
+			delete(ids, n)
+		} else {
+			ids[n] = *id
+		}
+
+		return nil
+	case event.Type == "patch" && index == "":
+		return mergeIDs(ids, event.Data)
+	}
+
+	return errors.New("unsupported event")
+}
+
+// mergeIDs sets the entries in data, an array or object of IDs, in ids.
+// Null entries are removed.
+func mergeIDs(ids map[int]int, data json.RawMessage) error {
+	var list []*int
+	if err := json.Unmarshal(data, &list); err == nil {
+		for n, id := range list {
+			if id != nil {
+				ids[n] = *id
+			}
+		}
+
+		return nil
+	}
+
+	var object map[string]*int
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+
+	for key, id := range object {
+		n, err := strconv.Atoi(key)
+		if err != nil {
+			return err
+		}
+
+		if id == nil {
+			delete(ids, n)
+		} else {
+			ids[n] = *id
+		}
+	}
+
+	return nil
+}
+
+// snapshot returns ids in index order.
+func snapshot(ids map[int]int) []int {
+	keys := make([]int, 0, len(ids))
+	for n := range ids {
+		keys = append(keys, n)
+	}
+
+	slices.Sort(keys)
+
+	list := make([]int, len(keys))
+	for i, n := range keys {
+		list[i] = ids[n]
+	}
+
+	return list
+}
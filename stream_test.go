@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadEvents(t *testing.T) {
+	f, err := os.Open("testdata/topstories.sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var events []Event
+	err = readEvents(f, func(event Event) bool {
+		events = append(events, event)
+		return true
+	})
+
+	// the stream ends at the cancel event, before the last put
+	if err == nil || !strings.Contains(err.Error(), "cancel") {
+		t.Errorf("err = %v, want the stream to be cancelled", err)
+	}
+
+	want := []Event{
+		{Type: "put", Path: "/", Data: []byte("[3,1,2]")},
+		{Type: "patch", Path: "/", Data: []byte(`{"1":4,"2":null}`)},
+		{Type: "put", Path: "/3", Data: []byte("5")},
+		{Type: "put", Path: "/", Data: []byte(`{"0":6,"1":7}`)},
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+
+	for i := range want {
+		if events[i].Type != want[i].Type || events[i].Path != want[i].Path || string(events[i].Data) != string(want[i].Data) {
+			t.Errorf("event %d = %s %s %s, want %s %s %s", i, events[i].Type, events[i].Path, events[i].Data, want[i].Type, want[i].Path, want[i].Data)
+		}
+	}
+
+	// applying them in turn follows the list
+	ids := make(map[int]int)
+	var lists [][]int
+	for _, event := range events {
+		if err := applyIDs(ids, event); err != nil {
+			t.Fatal(err)
+		}
+
+		lists = append(lists, snapshot(ids))
+	}
+
+	for i, want := range [][]int{{3, 1, 2}, {3, 4}, {3, 4, 5}, {6, 7}} {
+		if !slices.Equal(lists[i], want) {
+			t.Errorf("after event %d, ids = %v, want %v", i, lists[i], want)
+		}
+	}
+}
+
+func TestReadEventsStop(t *testing.T) {
+	stream := "event: put\ndata: {\"path\":\"/\",\"data\":[1]}\n\nevent: put\ndata: {\"path\":\"/\",\"data\":[2]}\n\n"
+
+	var n int
+	err := readEvents(strings.NewReader(stream), func(Event) bool {
+		n++
+		return false
+	})
+
+	if err != nil || n != 1 {
+		t.Errorf("readEvents() = %v after %d events, want nil after 1", err, n)
+	}
+}
+
+func TestReadEventsUnexpectedEOF(t *testing.T) {
+	stream := "event: put\ndata: {\"path\":\"/\",\"data\":[1]}\n\nevent: put\n"
+	if err := readEvents(strings.NewReader(stream), func(Event) bool { return true }); err != io.ErrUnexpectedEOF {
+		t.Errorf("err = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			http.Error(w, "not a stream", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: put\ndata: {\"path\":\"/\",\"data\":[1,2]}\n\n")
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := newTestHN(t, WithBaseURL(base)).Stream(context.Background(), "/topstories.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, ok := <-events
+	if !ok || event.Type != "put" || string(event.Data) != "[1,2]" {
+		t.Errorf("first event = %+v, want a put of [1,2]", event)
+	}
+
+	if _, ok := <-events; ok {
+		t.Error("stream did not end when the server closed it")
+	}
+}
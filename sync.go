@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// syncJournal is the cache key recording when each story was last synced
+// in full, so an interrupted sync picks up where it left off.
+const syncJournal = "/sync.json"
+
+func runSync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: termhnal sync [flags]")
+		fmt.Fprintln(fs.Output(), "Download story lists and their comments for offline reading.")
+		fs.PrintDefaults()
+	}
+
+	flags := NewClientFlags(fs)
+	lists := fs.String("lists", "top", "comma separated story lists to sync: top, new, best, ask, show, job")
+	depth := fs.String("depth", "all", "levels of comments to fetch for each story, or all")
+	limit := fs.Int("limit", 30, "stories to sync from each list, or 0 for all of them")
+	maxAge := fs.Duration("max-age", time.Hour, "skip stories which were synced in full more recently than this")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.offline || flags.noCache {
+		return errors.New("sync requires the cache and the network")
+	}
+
+	levels := -1
+	if *depth != "all" {
+		n, err := strconv.Atoi(*depth)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid -depth %q", *depth)
+		}
+
+		levels = n
+	}
+
+	hn, err := flags.HN()
+	if err != nil {
+		return err
+	}
+	defer hn.Close()
+
+	if hn.cache == nil {
+		return errors.New("sync requires the cache")
+	}
+
+	sources := map[string]func(context.Context) ([]int, error){
+		"top":  hn.Top,
+		"new":  hn.New,
+		"best": hn.Best,
+		"ask":  hn.Ask,
+		"show": hn.Show,
+		"job":  hn.Job,
+	}
+
+	var names []string
+	for _, name := range strings.Split(*lists, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := sources[name]; !ok {
+			return fmt.Errorf("unknown list %q", name)
+		}
+
+		names = append(names, name)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	journal := make(map[int]int64)
+	if body, _, ok := hn.cache.Get(syncJournal); ok {
+		// start over if the journal is corrupt
+		_ = json.Unmarshal(body, &journal)
+	}
+
+	var synced, skipped, failed int
+	for _, name := range names {
+		ids, err := sources[name](ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		if *limit > 0 && len(ids) > *limit {
+			ids = ids[:*limit]
+		}
+
+		for i, id := range ids {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			prefix := fmt.Sprintf("%s [%d/%d] %d", name, i+1, len(ids), id)
+			if at, ok := journal[id]; ok && time.Since(time.Unix(at, 0)) < *maxAge {
+				fmt.Println(prefix, "up to date")
+				skipped++
+				continue
+			}
+
+			if err := syncStory(ctx, hn, prefix, i, id, levels); incomplete(ctx, err) {
+				// leave it out of the journal so the next run retries it
+				failed++
+				continue
+			}
+
+			synced++
+			journal[id] = time.Now().Unix()
+			body, err := json.Marshal(journal)
+			if err != nil {
+				return err
+			}
+
+			if err := hn.cache.Put(syncJournal, body); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("synced %d, up to date %d, failed %d\n", synced, skipped, failed)
+	return nil
+}
+
+// syncStory fetches the story id with its poll options and levels of
+// comments, returning the errors of any items which failed to load.
+func syncStory(ctx context.Context, hn *HN, prefix string, rank, id, levels int) error {
+	story, err := hn.Story(ctx, rank, id)
+	if err != nil {
+		fmt.Println(prefix, "failed:", err)
+		return err
+	}
+
+	optionsErr := hn.Options(ctx, story)
+	if optionsErr != nil {
+		fmt.Println(prefix, "poll options failed:", optionsErr)
+	}
+
+	var fetched, missing, errored atomic.Int64
+	err = hn.Walk(ctx, story.Item, levels, func(_ *Comment, err error) {
+		switch {
+		case err == nil:
+			fetched.Add(1)
+		case incomplete(ctx, err):
+			errored.Add(1)
+		default:
+			missing.Add(1)
+		}
+	})
+
+	fmt.Printf("%s %s (%d comments", prefix, story.Item.Title, fetched.Load())
+	if n := missing.Load(); n > 0 {
+		fmt.Printf(", %d missing", n)
+	}
+	if n := errored.Load(); n > 0 {
+		fmt.Printf(", %d failed", n)
+	}
+	fmt.Println(")")
+
+	return errors.Join(optionsErr, err)
+}
+
+// incomplete reports whether err leaves a story worth syncing again, rather
+// than only items which are gone for good, e.g. missing ones.
+func incomplete(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return ctx.Err() != nil || errors.Is(err, ErrUnavailable) || transient(ctx, err)
+}
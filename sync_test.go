@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncJournal(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		kid     func(w http.ResponseWriter)
+		journal bool
+	}{
+		{"missing comment", func(w http.ResponseWriter) { fmt.Fprint(w, "null") }, true},
+		{"deleted comment", func(w http.ResponseWriter) { fmt.Fprint(w, `{"id":2,"deleted":true}`) }, true},
+		{"server error", func(w http.ResponseWriter) { http.Error(w, "oops", http.StatusInternalServerError) }, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/topstories.json":
+					fmt.Fprint(w, "[1]")
+				case "/item/1.json":
+					fmt.Fprint(w, `{"id":1,"type":"story","title":"story","kids":[2]}`)
+				case "/item/2.json":
+					tt.kid(w)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			if err := runSync([]string{"-api", server.URL, "-cache-dir", dir, "-retries", "0"}); err != nil {
+				t.Fatal(err)
+			}
+
+			base, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			journal := make(map[int]int64)
+			if body, _, ok := NewCache(filepath.Join(dir, CacheNamespace(base))).Get(syncJournal); ok {
+				if err := json.Unmarshal(body, &journal); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, ok := journal[1]; ok != tt.journal {
+				t.Errorf("story journaled = %t, want %t", ok, tt.journal)
+			}
+		})
+	}
+}
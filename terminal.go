@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"sync"
+
+	bbt "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+)
+
+// Terminal is the program's output. Frames and escape sequences written
+// from commands, e.g. OSC 52, share a lock so they never interleave.
+type Terminal struct {
+	*os.File
+
+	mu sync.Mutex
+}
+
+func NewTerminal(f *os.File) *Terminal {
+	return &Terminal{File: f}
+}
+
+func (t *Terminal) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.File.Write(b)
+}
+
+func (t *Terminal) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
+
+// WatchSize sends the terminal's size to p now and whenever it changes.
+// The program only queries the size of an *os.File, which t hides.
+func (t *Terminal) WatchSize(p *bbt.Program) {
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+
+	for {
+		width, height, err := term.GetSize(int(t.Fd()))
+		if err != nil {
+			// not a terminal
+			return
+		}
+
+		p.Send(bbt.WindowSizeMsg{Width: width, Height: height})
+		<-resized
+	}
+}
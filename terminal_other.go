@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// notifyResize does nothing where there is no signal for resizes, e.g.
+// Windows, so only the initial size is sent.
+func notifyResize(c chan<- os.Signal) {}
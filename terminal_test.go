@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+func TestTerminalWrites(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "terminal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	output := NewTerminal(f)
+	clipboard := NewClipboard(output, func(string) error { return nil })
+	clipboard.tmux, clipboard.screen = false, false
+
+	frame := strings.Repeat("frame ", 1<<12) + "\n"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			output.WriteString(frame)
+		}()
+
+		go func() {
+			defer wg.Done()
+			if err := clipboard.Copy("copied"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every write lands whole
+	seq := osc52.New("copied").String()
+	rest := string(b)
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, frame):
+			rest = rest[len(frame):]
+		case strings.HasPrefix(rest, seq):
+			rest = rest[len(seq):]
+		default:
+			t.Fatalf("unexpected output %.40q", rest)
+		}
+	}
+}
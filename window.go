@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	bbt "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/reflow/truncate"
 )
 
 type Window interface {
@@ -13,6 +17,25 @@ type Window interface {
 	View() string
 }
 
+// errorBanner is how long a new error is shown in the footer.
+const errorBanner = 5 * time.Second
+
+// status describes recent errors and outstanding requests for the footer.
+func status(hn *HN, log *ErrorLog) string {
+	if e, ok := log.Recent(errorBanner); ok {
+		text := truncate.StringWithTail(e.Err.Error(), 60, "...")
+		return fmt.Sprintf("error: %s | ! for details", text)
+	} else if notice, ok := log.Notice(errorBanner); ok {
+		return notice
+	} else if n := hn.Progress().Pending(); n > 0 {
+		return fmt.Sprintf("loading %d", n)
+	} else if hn.Offline() {
+		return "offline"
+	}
+
+	return ""
+}
+
 type WindowView struct {
 	header *PaneHeader
 	view   *PaneView
@@ -20,24 +43,25 @@ type WindowView struct {
 	active Pane
 }
 
-func NewWindowView() *WindowView {
+func NewWindowView(hn *HN, log *ErrorLog, config *Config) *WindowView {
 	var window WindowView
-	window.view = NewPaneView()
+	window.view = NewPaneView(hn, config)
 	window.header = NewPaneHeader(
 		PaneHeaderItem{
 			Name: "Back",
 			Func: func() bbt.Cmd {
-				return Activate("list")
+				window.view.Cancel()
+				return Activate("back")
 			},
 		},
 	)
 
 	window.footer = NewPaneFooter(
 		func() string {
-			return fmt.Sprintf("%3.f%%", window.view.model.ScrollPercent()*100)
+			return fmt.Sprintf("%3.f%%", window.view.ScrollPercent()*100)
 		},
 		func() string {
-			return ""
+			return status(hn, log)
 		},
 	)
 
@@ -65,10 +89,17 @@ func (w *WindowView) Update(msg bbt.Msg) (Window, bbt.Cmd) {
 			w.active.Deactivate()
 			w.active = w.view.Activate()
 		}
+	case ViewMsg[*Story], ViewMsg[*Comment], ViewMsg[*PollOpt], KidsMsg, UpdatesMsg, frameMsg:
+		// always deliver to the view, even while the header is focused
+		_, cmd := w.view.Update(msg)
+		return w, cmd
 	case bbt.KeyMsg:
 		switch msg.String() {
 		case "esc", "backspace":
-			return w, Activate("list")
+			w.view.Cancel()
+			return w, Activate("back")
+		case "!":
+			return w, Activate("errors")
 		}
 	case bbt.WindowSizeMsg:
 		for _, pane := range []Pane{w.header, w.footer, w.view} {
@@ -92,14 +123,102 @@ func (w *WindowView) View() string {
 	return sb.String()
 }
 
+type WindowReader struct {
+	header *PaneHeader
+	reader *PaneReader
+	footer *PaneFooter
+	active Pane
+}
+
+func NewWindowReader(hn *HN, log *ErrorLog, config *Config) *WindowReader {
+	var window WindowReader
+	window.reader = NewPaneReader(hn, config)
+	window.header = NewPaneHeader(
+		PaneHeaderItem{
+			Name: "Back",
+			Func: func() bbt.Cmd {
+				window.reader.Cancel()
+				return Activate("back")
+			},
+		},
+	)
+
+	window.footer = NewPaneFooter(
+		func() string {
+			return fmt.Sprintf("%3.f%%", window.reader.ScrollPercent()*100)
+		},
+		func() string {
+			return status(hn, log)
+		},
+	)
+
+	window.active = window.reader
+	return &window
+}
+
+func (w *WindowReader) Update(msg bbt.Msg) (Window, bbt.Cmd) {
+	switch msg := msg.(type) {
+	case ActivateMsg:
+		if msg == "toggle" {
+			switch w.active.(type) {
+			case *PaneHeader:
+				msg = "reader"
+			case *PaneReader:
+				msg = "header"
+			}
+		}
+
+		switch strings.ToLower(string(msg)) {
+		case "header":
+			w.active.Deactivate()
+			w.active = w.header.Activate()
+		case "reader":
+			w.active.Deactivate()
+			w.active = w.reader.Activate()
+		}
+	case ArticleMsg:
+		// always deliver to the reader, even while the header is focused
+		_, cmd := w.reader.Update(msg)
+		return w, cmd
+	case bbt.KeyMsg:
+		switch msg.String() {
+		case "esc", "backspace":
+			w.reader.Cancel()
+			return w, Activate("back")
+		case "!":
+			return w, Activate("errors")
+		}
+	case bbt.WindowSizeMsg:
+		for _, pane := range []Pane{w.header, w.footer, w.reader} {
+			pane.SetSize(msg.Width, msg.Height)
+			width, height := pane.Size()
+			msg.Width -= width
+			msg.Height -= height
+		}
+	}
+
+	var cmd bbt.Cmd
+	w.active, cmd = w.active.Update(msg)
+	return w, cmd
+}
+
+func (w *WindowReader) View() string {
+	var sb strings.Builder
+	sb.WriteString(w.header.View())
+	sb.WriteString(w.reader.View())
+	sb.WriteString(w.footer.View())
+	return sb.String()
+}
+
 type WindowList struct {
 	header *PaneHeader
 	list   *PaneList
 	footer *PaneFooter
+	prompt *PanePrompt
 	active Pane
 }
 
-func NewWindowList() *WindowList {
+func NewWindowList(hn *HN, log *ErrorLog, config *Config) *WindowList {
 	var items []PaneHeaderItem
 	values := []string{"Top", "New", "Best", "Ask", "Show", "Job"}
 	for i := range values {
@@ -116,14 +235,35 @@ func NewWindowList() *WindowList {
 		})
 	}
 
+	items = append(items, PaneHeaderItem{
+		Name: "Past",
+		Func: func() bbt.Cmd {
+			return Activate("past")
+		},
+	})
+
 	var window WindowList
 	window.header = NewPaneHeader(items...)
-	window.list = NewPaneList()
+	window.list = NewPaneList(hn, config)
+	window.prompt = NewPanePrompt("day: ", "YYYY-MM-DD, or empty for yesterday", func(s string) (bbt.Cmd, error) {
+		day := time.Now().UTC().AddDate(0, 0, -1)
+		if s = strings.TrimSpace(s); s != "" {
+			var err error
+			if day, err = time.Parse(time.DateOnly, s); err != nil {
+				return nil, fmt.Errorf("invalid date %q", s)
+			}
+		}
+
+		return bbt.Sequence(
+			List("clear"),
+			List(day),
+		), nil
+	})
 	window.footer = NewPaneFooter(
 		func() string {
 			return fmt.Sprintf("%d of %d", window.list.model.Paginator.Page+1, window.list.model.Paginator.TotalPages)
 		}, func() string {
-			return ""
+			return status(hn, log)
 		},
 	)
 
@@ -150,17 +290,45 @@ func (w *WindowList) Update(msg bbt.Msg) (Window, bbt.Cmd) {
 		case "list":
 			w.active.Deactivate()
 			w.active = w.list.Activate()
+		case "past":
+			w.active.Deactivate()
+			w.active = w.prompt.Activate()
+			return w, textinput.Blink
 		}
+	case ListMsg[string], ListMsg[time.Time], ListMsg[[]int], ListMsg[*Story], UpdatesMsg:
+		// always deliver to the list, even while the header is focused
+		_, cmd := w.list.Update(msg)
+		return w, cmd
 	case bbt.KeyMsg:
+		if w.active == w.prompt {
+			break
+		}
+
 		switch msg.String() {
-		case "1", "2", "3", "4", "5", "6":
+		case "1", "2", "3", "4", "5", "6", "7":
 			n, _ := strconv.Atoi(msg.String())
 			return w, bbt.Sequence(
 				Activate("header"),
 				Header(n-1),
 			)
+		case "!":
+			if !w.list.model.SettingFilter() {
+				return w, Activate("errors")
+			}
+		case "s":
+			if !w.list.model.SettingFilter() {
+				return w, Activate("search")
+			}
+		case "p":
+			if !w.list.model.SettingFilter() {
+				return w, bbt.Sequence(
+					Activate("header"),
+					Header(6),
+				)
+			}
 		}
 	case bbt.WindowSizeMsg:
+		w.prompt.SetSize(msg.Width, msg.Height)
 		for _, pane := range []Pane{w.header, w.footer, w.list} {
 			pane.SetSize(msg.Width, msg.Height)
 			width, height := pane.Size()
@@ -178,6 +346,283 @@ func (w *WindowList) View() string {
 	var sb strings.Builder
 	sb.WriteString(w.header.View())
 	sb.WriteString(w.list.View())
+	if w.active == w.prompt {
+		// the prompt takes the place of the footer
+		sb.WriteString(w.prompt.View())
+	} else {
+		sb.WriteString(w.footer.View())
+	}
+
+	return sb.String()
+}
+
+type WindowErrors struct {
+	header *PaneHeader
+	errors *PaneErrors
+	footer *PaneFooter
+	active Pane
+}
+
+func NewWindowErrors(hn *HN, log *ErrorLog) *WindowErrors {
+	var window WindowErrors
+	window.errors = NewPaneErrors(log)
+	window.header = NewPaneHeader(
+		PaneHeaderItem{
+			Name: "Back",
+			Func: func() bbt.Cmd {
+				return Activate("back")
+			},
+		},
+	)
+
+	window.footer = NewPaneFooter(
+		func() string {
+			return fmt.Sprintf("%d errors", len(log.errors))
+		},
+		func() string {
+			return status(hn, log)
+		},
+	)
+
+	window.active = window.errors
+	return &window
+}
+
+func (w *WindowErrors) Update(msg bbt.Msg) (Window, bbt.Cmd) {
+	switch msg := msg.(type) {
+	case ActivateMsg:
+		if msg == "toggle" {
+			switch w.active.(type) {
+			case *PaneHeader:
+				msg = "errors"
+			case *PaneErrors:
+				msg = "header"
+			}
+		}
+
+		switch strings.ToLower(string(msg)) {
+		case "header":
+			w.active.Deactivate()
+			w.active = w.header.Activate()
+		case "errors":
+			w.active.Deactivate()
+			w.active = w.errors.Activate()
+		}
+	case ErrorMsg:
+		_, cmd := w.errors.Update(msg)
+		return w, cmd
+	case bbt.KeyMsg:
+		switch msg.String() {
+		case "esc", "backspace":
+			return w, Activate("back")
+		}
+	case bbt.WindowSizeMsg:
+		for _, pane := range []Pane{w.header, w.footer, w.errors} {
+			pane.SetSize(msg.Width, msg.Height)
+			width, height := pane.Size()
+			msg.Width -= width
+			msg.Height -= height
+		}
+	}
+
+	var cmd bbt.Cmd
+	w.active, cmd = w.active.Update(msg)
+	return w, cmd
+}
+
+func (w *WindowErrors) View() string {
+	var sb strings.Builder
+	sb.WriteString(w.header.View())
+	sb.WriteString(w.errors.View())
+	sb.WriteString(w.footer.View())
+	return sb.String()
+}
+
+type WindowUser struct {
+	header *PaneHeader
+	user   *PaneUser
+	footer *PaneFooter
+	active Pane
+}
+
+func NewWindowUser(hn *HN, log *ErrorLog, config *Config) *WindowUser {
+	var window WindowUser
+	window.user = NewPaneUser(hn, config)
+	window.header = NewPaneHeader(
+		PaneHeaderItem{
+			Name: "Back",
+			Func: func() bbt.Cmd {
+				window.user.Cancel()
+				return Activate("back")
+			},
+		},
+	)
+
+	window.footer = NewPaneFooter(
+		func() string {
+			paginator := window.user.list.model.Paginator
+			return fmt.Sprintf("%d of %d", paginator.Page+1, paginator.TotalPages)
+		},
+		func() string {
+			return status(hn, log)
+		},
+	)
+
+	window.active = window.user
+	return &window
+}
+
+func (w *WindowUser) Update(msg bbt.Msg) (Window, bbt.Cmd) {
+	switch msg := msg.(type) {
+	case ActivateMsg:
+		if msg == "toggle" {
+			switch w.active.(type) {
+			case *PaneHeader:
+				msg = "user"
+			case *PaneUser:
+				msg = "header"
+			}
+		}
+
+		switch strings.ToLower(string(msg)) {
+		case "header":
+			w.active.Deactivate()
+			w.active = w.header.Activate()
+		case "user":
+			w.active.Deactivate()
+			w.active = w.user.Activate()
+		}
+	case UserMsg, ListMsg[[]int], ListMsg[*Story]:
+		// always deliver to the user, even while the header is focused
+		_, cmd := w.user.Update(msg)
+		return w, cmd
+	case bbt.KeyMsg:
+		switch msg.String() {
+		case "esc", "backspace":
+			if w.user.list.model.FilterState() == list.Unfiltered {
+				w.user.Cancel()
+				return w, Activate("back")
+			}
+		case "!":
+			if !w.user.list.model.SettingFilter() {
+				return w, Activate("errors")
+			}
+		}
+	case bbt.WindowSizeMsg:
+		for _, pane := range []Pane{w.header, w.footer, w.user} {
+			pane.SetSize(msg.Width, msg.Height)
+			width, height := pane.Size()
+			msg.Width -= width
+			msg.Height -= height
+		}
+	}
+
+	var cmd bbt.Cmd
+	w.active, cmd = w.active.Update(msg)
+	return w, cmd
+}
+
+func (w *WindowUser) View() string {
+	var sb strings.Builder
+	sb.WriteString(w.header.View())
+	sb.WriteString(w.user.View())
+	sb.WriteString(w.footer.View())
+	return sb.String()
+}
+
+type WindowSearch struct {
+	header *PaneHeader
+	search *PaneSearch
+	footer *PaneFooter
+	active Pane
+}
+
+func NewWindowSearch(hn *HN, log *ErrorLog, config *Config) *WindowSearch {
+	var window WindowSearch
+	window.search = NewPaneSearch(hn, config)
+	window.header = NewPaneHeader(
+		PaneHeaderItem{
+			Name: "Back",
+			Func: func() bbt.Cmd {
+				window.search.Cancel()
+				return Activate("back")
+			},
+		},
+	)
+
+	window.footer = NewPaneFooter(
+		func() string {
+			paginator := window.search.list.model.Paginator
+			return fmt.Sprintf("%d of %d", paginator.Page+1, paginator.TotalPages)
+		},
+		func() string {
+			return status(hn, log)
+		},
+	)
+
+	window.active = window.search
+	return &window
+}
+
+func (w *WindowSearch) Update(msg bbt.Msg) (Window, bbt.Cmd) {
+	switch msg := msg.(type) {
+	case ActivateMsg:
+		if msg == "toggle" {
+			switch w.active.(type) {
+			case *PaneHeader:
+				msg = "search"
+			case *PaneSearch:
+				msg = "header"
+			}
+		}
+
+		switch strings.ToLower(string(msg)) {
+		case "header":
+			w.active.Deactivate()
+			w.active = w.header.Activate()
+		case "search":
+			w.active.Deactivate()
+			w.active = w.search.Activate()
+			return w, textinput.Blink
+		}
+	case SearchMsg, ListMsg[[]int], ListMsg[*Story]:
+		// always deliver to the search, even while the header is focused
+		_, cmd := w.search.Update(msg)
+		return w, cmd
+	case bbt.KeyMsg:
+		if w.active == w.search && w.search.Typing() {
+			break
+		}
+
+		switch msg.String() {
+		case "esc", "backspace":
+			if w.search.list.model.FilterState() == list.Unfiltered {
+				w.search.Cancel()
+				return w, Activate("back")
+			}
+		case "!":
+			if !w.search.list.model.SettingFilter() {
+				return w, Activate("errors")
+			}
+		}
+	case bbt.WindowSizeMsg:
+		for _, pane := range []Pane{w.header, w.footer, w.search} {
+			pane.SetSize(msg.Width, msg.Height)
+			width, height := pane.Size()
+			msg.Width -= width
+			msg.Height -= height
+		}
+	}
+
+	var cmd bbt.Cmd
+	w.active, cmd = w.active.Update(msg)
+	return w, cmd
+}
+
+func (w *WindowSearch) View() string {
+	var sb strings.Builder
+	sb.WriteString(w.header.View())
+	sb.WriteString(w.search.View())
 	sb.WriteString(w.footer.View())
 	return sb.String()
 }
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/muesli/reflow/ansi"
+)
+
+func TestStatusTruncatesErrors(t *testing.T) {
+	var log ErrorLog
+	log.Add(ErrorMsg{Err: errors.New(strings.Repeat("ü", 100)), Time: time.Now()})
+
+	got := status(newTestHN(t), &log)
+	if !utf8.ValidString(got) {
+		t.Errorf("status() = %q, which is not valid UTF-8", got)
+	}
+
+	text := strings.TrimSuffix(strings.TrimPrefix(got, "error: "), " | ! for details")
+	if w := ansi.PrintableRuneWidth(text); w > 60 || !strings.HasSuffix(text, "...") {
+		t.Errorf("status() = %q, want the error cut to 60 columns", got)
+	}
+}